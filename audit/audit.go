@@ -3,18 +3,180 @@ package audit
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"sync"
 	"time"
+
+	"gotools/config"
+)
+
+var settings = config.DefaultConfig().Audit
+
+// Configure applique la section audit de la config, les destinations
+// (audit.sinks) ne sont recreees que si elles ont change
+func Configure(c config.AuditConfig) {
+	settings = c
+	configureSinks(c.Sinks)
+}
+
+// Action est le type d'operation sensible tracee
+type Action string
+
+const (
+	ActionKill   Action = "KILL"
+	ActionLock   Action = "LOCK"
+	ActionUnlock Action = "UNLOCK"
+	ActionChmod  Action = "CHMOD"
+)
+
+// Outcome est le resultat d'une action
+type Outcome string
+
+const (
+	OutcomeSuccess   Outcome = "success"
+	OutcomeFailure   Outcome = "failure"   // erreur ou action refusee (deja verrouille...)
+	OutcomeCancelled Outcome = "cancelled" // l'utilisateur n'a pas confirme
 )
 
-// Log ajoute une ligne horodatee dans out/audit.log
-func Log(outDir, action string) {
-	path := filepath.Join(outDir, "audit.log")
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// Event est une entree du journal, ecrite sur une ligne JSON
+type Event struct {
+	Time       time.Time         `json:"time"`
+	User       string            `json:"user"`
+	Host       string            `json:"host"`
+	PID        int               `json:"pid"`
+	Action     Action            `json:"action"`
+	Target     string            `json:"target"`
+	Params     map[string]string `json:"params,omitempty"`
+	Before     map[string]string `json:"before,omitempty"`
+	Outcome    Outcome           `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	DurationMS float64           `json:"duration_ms"`
+
+	// chainage : doivent rester les derniers champs (voir seal)
+	Seq      int64  `json:"seq"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash,omitempty"`
+	HMAC     string `json:"hmac,omitempty"`
+}
+
+// Start prepare un evenement, la duree est mesuree a partir de cet appel
+func Start(action Action, target string) *Event {
+	return &Event{Time: time.Now(), Action: action, Target: target}
+}
+
+// With ajoute un parametre a l'evenement (mode, nom du processus...)
+func (e *Event) With(key, value string) *Event {
+	if e.Params == nil {
+		e.Params = map[string]string{}
+	}
+	e.Params[key] = value
+	return e
+}
+
+// Was note l'etat de la cible avant l'action (ancien mode, proprietaire du lock...)
+func (e *Event) Was(key, value string) *Event {
+	if e.Before == nil {
+		e.Before = map[string]string{}
+	}
+	e.Before[key] = value
+	return e
+}
+
+// Finish renseigne le resultat et ecrit l'evenement, une erreur d'ecriture est affichee sur stderr
+func (e *Event) Finish(outDir string, outcome Outcome, err error) {
+	e.Outcome = outcome
 	if err != nil {
+		e.Error = err.Error()
+	}
+	e.DurationMS = float64(time.Since(e.Time).Microseconds()) / 1000
+	if err := Record(outDir, *e); err != nil {
 		fmt.Fprintf(os.Stderr, "Erreur audit log: %v\n", err)
-		return
 	}
-	defer f.Close()
-	fmt.Fprintf(f, "[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), action)
+}
+
+// serialise lecture du dernier hash + rotation + ecriture dans ce processus,
+// le verrou sur le fichier .lock fait de meme entre processus
+var writeMu sync.Mutex
+
+// Record complete le contexte (utilisateur, machine, PID), chaine l'evenement
+// au precedent, l'ajoute au journal d'audit (out/audit.log par defaut) en le
+// tournant si besoin, puis le transmet aux destinations configurees
+func Record(outDir string, e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.User, e.Host = identity()
+	e.PID = os.Getpid()
+
+	line, err := appendLocal(outDir, &e)
+	if err != nil {
+		return err
+	}
+	// hors verrou : une destination reseau lente ne bloque pas les autres ecrivains
+	return forward(outDir, &e, line)
+}
+
+// appendLocal ecrit l'evenement chaine dans le journal local et renvoie sa ligne
+func appendLocal(outDir string, e *Event) ([]byte, error) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	path := filepath.Join(outDir, settings.File)
+	unlock, err := lockPath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	prev, err := lastLink(path)
+	if err != nil {
+		return nil, err
+	}
+	// le maillon est lu avant la rotation : la chaine continue dans le nouveau fichier
+	if needsRotation(path, time.Now()) {
+		// le fichier ouvert doit etre ferme avant d'etre renomme (Windows)
+		if err := closeJournal(); err != nil {
+			return nil, err
+		}
+		if err := rotate(path, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	line, err := seal(e, prev, settings.HMACKey)
+	if err != nil {
+		return nil, fmt.Errorf("evenement invalide: %w", err)
+	}
+
+	if err := writeJournal(path, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+var (
+	identityOnce sync.Once
+	userName     string
+	hostName     string
+)
+
+// Owner identifie le processus courant, ex: "alice@poste1 pid=4242"
+func Owner() string {
+	u, h := identity()
+	return fmt.Sprintf("%s@%s pid=%d", u, h, os.Getpid())
+}
+
+// identity renvoie l'utilisateur OS et le nom de la machine (calcules une seule fois)
+func identity() (string, string) {
+	identityOnce.Do(func() {
+		if u, err := user.Current(); err == nil {
+			userName = u.Username
+		} else if name := os.Getenv("USER"); name != "" {
+			userName = name
+		} else {
+			userName = os.Getenv("USERNAME")
+		}
+		hostName, _ = os.Hostname()
+	})
+	return userName, hostName
 }
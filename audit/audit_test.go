@@ -1,22 +1,69 @@
 package audit
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
-func TestLogWritesAuditFile(t *testing.T) {
+func TestFinishWritesJSONEvent(t *testing.T) {
 	outDir := t.TempDir()
-	Log(outDir, "LOCK data/input.txt")
+	Start(ActionLock, "data/input.txt").With("lock", "input.txt.lock").Finish(outDir, OutcomeSuccess, nil)
+	Start(ActionChmod, "data/input.txt").Finish(outDir, OutcomeFailure, errors.New("permission denied"))
 
-	path := filepath.Join(outDir, "audit.log")
-	data, err := os.ReadFile(path)
+	data, err := os.ReadFile(filepath.Join(outDir, "audit.log"))
 	if err != nil {
 		t.Fatalf("read audit.log: %v", err)
 	}
-	if !strings.Contains(string(data), "LOCK data/input.txt") {
-		t.Fatalf("audit content missing action: %q", string(data))
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), string(data))
+	}
+
+	var ev Event
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("invalid json line: %v", err)
+	}
+	if ev.Action != ActionLock || ev.Target != "data/input.txt" || ev.Outcome != OutcomeSuccess {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if ev.PID != os.Getpid() || ev.Params["lock"] != "input.txt.lock" || ev.Time.IsZero() {
+		t.Fatalf("missing context: %+v", ev)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &ev); err != nil {
+		t.Fatalf("invalid json line: %v", err)
+	}
+	if ev.Outcome != OutcomeFailure || ev.Error != "permission denied" {
+		t.Fatalf("unexpected failure event: %+v", ev)
+	}
+}
+
+func TestConcurrentRecordsKeepChain(t *testing.T) {
+	outDir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			Start(ActionChmod, strings.Repeat("x", 4096)).With("n", strconv.Itoa(i)).Finish(outDir, OutcomeSuccess, nil)
+		}(i)
+	}
+	wg.Wait()
+	if err := Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	report, err := Verify(filepath.Join(outDir, "audit.log"), "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if report.Problem != nil || report.Entries != 20 {
+		t.Fatalf("expected 20 chained entries, got %d (%v)", report.Entries, report.Problem)
 	}
 }
@@ -0,0 +1,289 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Chainage des entrees : chaque ligne porte le numero (seq) et le hash de la
+// precedente (prev_hash), puis son propre hash SHA-256 calcule sur la ligne
+// sans les champs hash/hmac. Avec une cle, un HMAC empeche de recalculer la chaine.
+
+// link identifie la derniere entree ecrite
+type link struct {
+	Seq  int64
+	Hash string
+}
+
+// seal numerote l'evenement, le rattache a prev et renvoie la ligne JSON finale
+func seal(e *Event, prev link, key string) ([]byte, error) {
+	e.Seq = prev.Seq + 1
+	e.PrevHash = prev.Hash
+	e.Hash, e.HMAC = "", ""
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	e.Hash = hashOf(body)
+	if key != "" {
+		e.HMAC = hmacOf(key, body)
+	}
+	return json.Marshal(e)
+}
+
+func hashOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacOf(key string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(key))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// bodyOf retrouve la ligne telle qu'elle a ete hashee (sans hash/hmac).
+// Renvoie false si la fin de ligne ne correspond pas exactement a ce que seal ecrit.
+func bodyOf(line []byte, e *Event) ([]byte, bool) {
+	i := bytes.LastIndex(line, []byte(`,"hash":"`))
+	if i < 0 {
+		return nil, false
+	}
+	tail := fmt.Sprintf(`,"hash":%q`, e.Hash)
+	if e.HMAC != "" {
+		tail += fmt.Sprintf(`,"hmac":%q`, e.HMAC)
+	}
+	if string(line[i:]) != tail+"}" {
+		return nil, false
+	}
+	body := append(append([]byte{}, line[:i]...), '}')
+	return body, true
+}
+
+// linkOf renvoie le maillon d'une ligne ; une ligne non chainee (ancien format)
+// est hashee telle quelle pour que la suivante s'y rattache quand meme
+func linkOf(line []byte) link {
+	var e Event
+	if json.Unmarshal(line, &e) == nil && e.Hash != "" {
+		return link{Seq: e.Seq, Hash: e.Hash}
+	}
+	return link{Hash: hashOf(line)}
+}
+
+// lastLink lit le dernier maillon du journal, ou du dernier journal tourne si
+// le courant n'existe pas (vide si aucun journal)
+func lastLink(path string) (link, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return lastRotatedLink(path)
+	}
+	if err != nil {
+		return link{}, err
+	}
+	defer f.Close()
+
+	line, err := readLastLine(f)
+	if err != nil || len(line) == 0 {
+		return link{}, err
+	}
+	return linkOf(line), nil
+}
+
+func lastRotatedLink(path string) (link, error) {
+	files, err := rotatedFiles(path)
+	if err != nil || len(files) == 0 {
+		return link{}, err
+	}
+	r, err := openLog(files[len(files)-1].path)
+	if err != nil {
+		return link{}, err
+	}
+	defer r.Close()
+
+	var last []byte
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if line := bytes.TrimRight(sc.Bytes(), "\r"); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := sc.Err(); err != nil || last == nil {
+		return link{}, err
+	}
+	return linkOf(last), nil
+}
+
+// readLastLine lit le fichier depuis la fin par blocs jusqu'au dernier saut de ligne
+func readLastLine(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	const chunk = 4096
+	end := info.Size()
+	var buf []byte
+	for pos := end; pos > 0; {
+		n := int64(chunk)
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		part := make([]byte, n)
+		if _, err := f.ReadAt(part, pos); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(part, buf...)
+		trimmed := bytes.TrimRight(buf, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+	}
+	return bytes.TrimRight(buf, "\r\n"), nil
+}
+
+// Problem decrit la premiere rupture trouvee dans la chaine
+type Problem struct {
+	File   string
+	Line   int
+	Kind   string // "modifiee", "inseree" ou "supprimee"
+	Detail string
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s:%d: entree %s : %s", p.File, p.Line, p.Kind, p.Detail)
+}
+
+// VerifyReport est le resultat d'une verification
+type VerifyReport struct {
+	Files    int      // fichiers parcourus (journaux tournes + courant)
+	FirstSeq int64    // > 1 si les plus anciens ont ete supprimes par la retention
+	Entries  int      // entrees chainees verifiees
+	Legacy   int      // lignes anciennes (non chainees) en debut de journal
+	LastSeq  int64    // dernier maillon valide, a comparer avec une copie externe
+	LastHash string   // (une suppression des dernieres entrees ne se voit pas sinon)
+	Problem  *Problem // nil si la chaine est intacte
+}
+
+// verifier parcourt les lignes dans l'ordre d'ecriture
+type verifier struct {
+	key string
+	// la retention supprime les plus vieux journaux : le premier fichier tourne
+	// peut commencer au milieu de la chaine
+	openStart bool
+
+	prev     link
+	prevLine int
+	prevFile string
+	chained  bool
+	report   VerifyReport
+}
+
+func (v *verifier) check(file string, lineNo int, raw []byte) *Problem {
+	fail := func(kind, format string, args ...any) *Problem {
+		return &Problem{File: file, Line: lineNo, Kind: kind, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	var e Event
+	if json.Unmarshal(raw, &e) != nil || e.Hash == "" {
+		if v.chained {
+			return fail("inseree", "ligne non chainee au milieu du journal")
+		}
+		v.report.Legacy++
+		v.prev = link{Hash: hashOf(raw)}
+		v.prevFile, v.prevLine = file, lineNo
+		return nil
+	}
+
+	body, ok := bodyOf(raw, &e)
+	if !ok || hashOf(body) != e.Hash {
+		return fail("modifiee", "le contenu ne correspond plus a son hash (seq %d)", e.Seq)
+	}
+	if v.key != "" && !hmac.Equal([]byte(e.HMAC), []byte(hmacOf(v.key, body))) {
+		return fail("modifiee", "HMAC invalide, entree reecrite sans la cle (seq %d)", e.Seq)
+	}
+	if v.openStart && !v.chained && v.prevLine == 0 {
+		v.prev = link{Seq: e.Seq - 1, Hash: e.PrevHash}
+	}
+	if !v.chained {
+		v.report.FirstSeq = e.Seq
+	}
+
+	switch {
+	case e.Seq > v.prev.Seq+1:
+		return fail("supprimee", "%d entree(s) manquante(s) avant seq %d", e.Seq-v.prev.Seq-1, e.Seq)
+	case e.Seq <= v.prev.Seq:
+		return fail("inseree", "seq %d apres seq %d", e.Seq, v.prev.Seq)
+	case e.PrevHash != v.prev.Hash:
+		if v.prevLine == 0 {
+			return fail("supprimee", "le debut du journal a ete retire (prev_hash inattendu)")
+		}
+		return &Problem{File: v.prevFile, Line: v.prevLine, Kind: "modifiee",
+			Detail: fmt.Sprintf("ne correspond plus au prev_hash de seq %d", e.Seq)}
+	}
+
+	v.chained = true
+	v.prev = link{Seq: e.Seq, Hash: e.Hash}
+	v.prevFile, v.prevLine = file, lineNo
+	v.report.Entries++
+	v.report.LastSeq, v.report.LastHash = e.Seq, e.Hash
+	return nil
+}
+
+// feed verifie un fichier ligne par ligne, s'arrete a la premiere rupture
+func (v *verifier) feed(path string) error {
+	r, err := openLog(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		raw := bytes.TrimRight(sc.Bytes(), "\r")
+		if len(raw) == 0 {
+			continue
+		}
+		if p := v.check(path, lineNo, raw); p != nil {
+			v.report.Problem = p
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// Verify parcourt la chaine du journal, journaux tournes compris, et signale la
+// premiere entree modifiee, inseree ou supprimee. key est la cle HMAC (vide si non utilisee).
+func Verify(path, key string) (*VerifyReport, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("aucun journal %s", path)
+	}
+	// avec une retention, le debut de la chaine a pu etre supprime meme si
+	// aucun journal tourne ne subsiste : la premiere entree sert d'ancre
+	retention := settings.MaxFiles > 0 || settings.MaxAgeDays > 0
+	v := &verifier{key: key, openStart: retention}
+	for _, f := range files {
+		v.report.Files++
+		if err := v.feed(f); err != nil {
+			return nil, fmt.Errorf("lecture %s: %w", f, err)
+		}
+		if v.report.Problem != nil {
+			break
+		}
+	}
+	return &v.report, nil
+}
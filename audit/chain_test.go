@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeChain(t *testing.T, key string, n int) (string, []string) {
+	t.Helper()
+	old := settings
+	settings.HMACKey = key
+	t.Cleanup(func() { settings = old })
+
+	outDir := t.TempDir()
+	for i := 0; i < n; i++ {
+		Start(ActionChmod, "file"+string(rune('a'+i))).Finish(outDir, OutcomeSuccess, nil)
+	}
+	path := filepath.Join(outDir, "audit.log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit.log: %v", err)
+	}
+	return path, strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+}
+
+func rewrite(t *testing.T, path string, lines []string) {
+	t.Helper()
+	body := strings.Join(lines, "")
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+}
+
+func TestVerifyIntactChain(t *testing.T) {
+	path, _ := writeChain(t, "", 3)
+	rep, err := Verify(path, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if rep.Problem != nil || rep.Entries != 3 || rep.LastSeq != 3 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	cases := map[string]struct {
+		edit func([]string) []string
+		kind string
+		line int
+	}{
+		"altered": {func(l []string) []string {
+			l[1] = strings.Replace(l[1], "fileb", "other", 1)
+			return l
+		}, "modifiee", 2},
+		"deleted": {func(l []string) []string {
+			return append(l[:1], l[2:]...)
+		}, "supprimee", 2},
+		"inserted": {func(l []string) []string {
+			return append(l[:2], append([]string{l[0]}, l[2:]...)...)
+		}, "inseree", 3},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			path, lines := writeChain(t, "", 3)
+			rewrite(t, path, c.edit(lines))
+			rep, err := Verify(path, "")
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if rep.Problem == nil || rep.Problem.Kind != c.kind || rep.Problem.Line != c.line {
+				t.Fatalf("problem = %+v, want %s at line %d", rep.Problem, c.kind, c.line)
+			}
+		})
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	path, _ := writeChain(t, "s3cret", 2)
+	if rep, _ := Verify(path, "s3cret"); rep.Problem != nil {
+		t.Fatalf("unexpected problem with right key: %v", rep.Problem)
+	}
+	if rep, _ := Verify(path, "wrong"); rep.Problem == nil {
+		t.Fatal("expected HMAC failure with wrong key")
+	}
+}
+
+func TestLegacyLinesBeforeChain(t *testing.T) {
+	outDir := t.TempDir()
+	path := filepath.Join(outDir, "audit.log")
+	if err := os.WriteFile(path, []byte("[2024-01-01 10:00:00] LOCK data/input.txt\n"), 0644); err != nil {
+		t.Fatalf("write legacy: %v", err)
+	}
+	Start(ActionUnlock, "data/input.txt").Finish(outDir, OutcomeSuccess, nil)
+
+	rep, err := Verify(path, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if rep.Problem != nil || rep.Legacy != 1 || rep.Entries != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// journal garde le fichier d'audit ouvert entre deux evenements, protege par writeMu
+var journal struct {
+	f    *os.File
+	path string
+}
+
+// writeJournal ajoute une ligne en une seule ecriture, puis synchronise selon
+// audit.durability. Doit etre appele sous writeMu et sous le verrou du journal.
+func writeJournal(path string, line []byte) error {
+	f, err := openJournal(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("ecriture %s: %w", path, err)
+	}
+	if settings.Durability == "always" {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("fsync %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// openJournal reutilise le fichier ouvert tant qu'il s'agit toujours de path :
+// un autre processus a pu le tourner (renommer) depuis la derniere ecriture
+func openJournal(path string) (*os.File, error) {
+	if journal.f != nil && journal.path == path {
+		cur, err1 := journal.f.Stat()
+		disk, err2 := os.Stat(path)
+		if err1 == nil && err2 == nil && os.SameFile(cur, disk) {
+			return journal.f, nil
+		}
+	}
+	if err := closeJournal(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	journal.f, journal.path = f, path
+	return f, nil
+}
+
+// closeJournal synchronise (sauf durability none) et ferme le fichier ouvert
+func closeJournal() error {
+	if journal.f == nil {
+		return nil
+	}
+	var err error
+	if settings.Durability != "none" {
+		err = journal.f.Sync()
+	}
+	err = errors.Join(err, journal.f.Close())
+	journal.f, journal.path = nil, ""
+	return err
+}
+
+// Flush force l'ecriture sur disque du journal (fsync), quel que soit audit.durability
+func Flush() error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if journal.f == nil {
+		return nil
+	}
+	return journal.f.Sync()
+}
+
+// Close synchronise et ferme le journal puis les destinations, a appeler en fin de programme
+func Close() error {
+	writeMu.Lock()
+	err := closeJournal()
+	writeMu.Unlock()
+	return errors.Join(err, closeSinks())
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// delai d'attente du verrou (variable pour les tests)
+var lockTimeout = 5 * time.Second
+
+// lockPath prend un verrou consultatif (flock / LockFileEx) sur path.lock,
+// partage par tous les processus gotools qui ecrivent ce journal. Le fichier
+// reste en place : le systeme libere le verrou si le processus meurt.
+// Renvoie la fonction de liberation.
+func lockPath(path string) (func(), error) {
+	lock := path + ".lock"
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("verrou %s: %w", lock, err)
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("verrou %s: %w", lock, err)
+		}
+		if ok {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("journal d'audit verrouille par un autre processus (%s)", lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
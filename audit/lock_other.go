@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package audit
+
+import "os"
+
+// pas de verrou entre processus sur ces systemes, writeMu protege ce processus
+func tryLock(f *os.File) (bool, error) { return true, nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockPathExcludesOtherHolders(t *testing.T) {
+	old := lockTimeout
+	lockTimeout = 50 * time.Millisecond
+	defer func() { lockTimeout = old }()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	unlock, err := lockPath(path)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+	// le verrou est lie au descripteur : un second open est refuse, meme dans ce processus
+	if _, err := lockPath(path); err == nil {
+		t.Fatal("expected second lock to time out")
+	}
+	unlock()
+
+	unlock, err = lockPath(path)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selectionne des evenements, les champs vides ne filtrent pas
+type Filter struct {
+	Since    time.Time
+	Until    time.Time
+	Actions  []Action
+	Target   string // PID exact, ou partie du chemin
+	Outcomes []Outcome
+	Last     int // garde les N plus recents (0 = tous)
+}
+
+func (f Filter) match(e Event) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	if len(f.Actions) > 0 && !containsAction(f.Actions, e.Action) {
+		return false
+	}
+	if len(f.Outcomes) > 0 && !containsOutcome(f.Outcomes, e.Outcome) {
+		return false
+	}
+	if f.Target != "" {
+		if _, err := strconv.Atoi(f.Target); err == nil {
+			return e.Target == f.Target
+		}
+		return strings.Contains(strings.ToLower(e.Target), strings.ToLower(f.Target))
+	}
+	return true
+}
+
+func containsAction(list []Action, a Action) bool {
+	for _, x := range list {
+		if strings.EqualFold(string(x), string(a)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOutcome(list []Outcome, o Outcome) bool {
+	for _, x := range list {
+		if strings.EqualFold(string(x), string(o)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query lit le journal (journaux tournes compris) et renvoie les evenements
+// retenus par le filtre, du plus ancien au plus recent. Les lignes de l'ancien
+// format texte sont ignorees.
+func Query(path string, f Filter) ([]Event, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, file := range files {
+		r, err := openLog(file)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			var e Event
+			if json.Unmarshal(bytes.TrimSpace(sc.Bytes()), &e) != nil || e.Action == "" {
+				continue
+			}
+			if f.match(e) {
+				events = append(events, e)
+			}
+		}
+		err = sc.Err()
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("lecture %s: %w", file, err)
+		}
+	}
+	if f.Last > 0 && len(events) > f.Last {
+		events = events[len(events)-f.Last:]
+	}
+	return events, nil
+}
+
+// ParseTime accepte une date (2006-01-02, 2006-01-02 15:04, RFC 3339) ou une
+// duree relative a now : "90m", "24h", "7d"
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "d") {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date invalide %q (ex: 2026-10-12, \"2026-10-12 08:00\", 24h, 7d)", s)
+}
+
+// ParseUntil lit une borne de fin incluse comme ParseTime, mais une date seule
+// couvre toute la journee et "2026-10-12 08:30" toute la minute
+func ParseUntil(s string, now time.Time) (time.Time, error) {
+	t, err := ParseTime(s, now)
+	if err != nil {
+		return t, err
+	}
+	s = strings.TrimSpace(s)
+	if _, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	if _, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t.Add(time.Minute - time.Nanosecond), nil
+	}
+	return t, nil
+}
+
+// WriteEvents affiche les evenements au format table, json (une ligne par evenement) ou csv
+func WriteEvents(w io.Writer, events []Event, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"time", "user", "host", "pid", "action", "target", "outcome", "error", "duration_ms", "params", "seq", "before"})
+		for _, e := range events {
+			cw.Write([]string{
+				e.Time.Format(time.RFC3339), e.User, e.Host, strconv.Itoa(e.PID),
+				string(e.Action), e.Target, string(e.Outcome), e.Error,
+				strconv.FormatFloat(e.DurationMS, 'f', 3, 64), formatParams(e.Params),
+				strconv.FormatInt(e.Seq, 10), formatParams(e.Before),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "table", "":
+		fmt.Fprintf(w, "  %-19s %-7s %-30s %-9s %-12s %s\n", "DATE", "ACTION", "CIBLE", "RESULTAT", "UTILISATEUR", "DETAIL")
+		fmt.Fprintf(w, "  %s\n", strings.Repeat("-", 95))
+		for _, e := range events {
+			detail := e.Error
+			if detail == "" {
+				detail = formatParams(e.Params)
+			}
+			if len(e.Before) > 0 {
+				detail += " (avant: " + formatParams(e.Before) + ")"
+			}
+			fmt.Fprintf(w, "  %-19s %-7s %-30s %-9s %-12s %s\n",
+				e.Time.Local().Format("2006-01-02 15:04:05"), e.Action, shorten(e.Target, 30),
+				e.Outcome, shorten(e.User, 12), detail)
+		}
+		fmt.Fprintf(w, "  Total: %d\n", len(events))
+		return nil
+	default:
+		return fmt.Errorf("format %q inconnu (table, json, csv)", format)
+	}
+}
+
+func formatParams(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + params[k]
+	}
+	return strings.Join(parts, " ")
+}
+
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return "..." + string(r[len(r)-n+3:])
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryFilters(t *testing.T) {
+	outDir := t.TempDir()
+	Start(ActionKill, "1234").With("name", "sleep").Finish(outDir, OutcomeSuccess, nil)
+	Start(ActionKill, "12345").Finish(outDir, OutcomeFailure, errors.New("no such process"))
+	Start(ActionLock, "data/input.txt").Finish(outDir, OutcomeCancelled, nil)
+	Start(ActionChmod, "data/input.txt").Finish(outDir, OutcomeSuccess, nil)
+	path := filepath.Join(outDir, "audit.log")
+
+	cases := []struct {
+		name string
+		f    Filter
+		want int
+	}{
+		{"all", Filter{}, 4},
+		{"action", Filter{Actions: []Action{"kill"}}, 2},
+		{"pid exact", Filter{Target: "1234"}, 1},
+		{"path part", Filter{Target: "input"}, 2},
+		{"outcome", Filter{Outcomes: []Outcome{OutcomeSuccess}}, 2},
+		{"last", Filter{Last: 3}, 3},
+		{"future", Filter{Since: time.Now().Add(time.Hour)}, 0},
+	}
+	for _, c := range cases {
+		events, err := Query(path, c.f)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(events) != c.want {
+			t.Fatalf("%s: got %d events, want %d", c.name, len(events), c.want)
+		}
+	}
+
+	events, _ := Query(path, Filter{Actions: []Action{ActionKill}})
+	var buf bytes.Buffer
+	if err := WriteEvents(&buf, events, "csv"); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "time,user,host") || !strings.Contains(lines[2], "no such process") {
+		t.Fatalf("unexpected csv: %q", buf.String())
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	cases := map[string]time.Time{
+		"7d":               now.AddDate(0, 0, -7),
+		"90m":              now.Add(-90 * time.Minute),
+		"2026-10-12":       time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local),
+		"2026-10-12 08:30": time.Date(2026, 10, 12, 8, 30, 0, 0, time.Local),
+	}
+	for in, want := range cases {
+		got, err := ParseTime(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("ParseTime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseTime("last week", now); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseUntilCoversNamedDay(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	until, err := ParseUntil("2026-10-12", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Filter{Until: until}
+	if !f.match(Event{Time: time.Date(2026, 10, 12, 23, 59, 59, 0, time.Local)}) {
+		t.Fatal("event late on the named day excluded")
+	}
+	if f.match(Event{Time: time.Date(2026, 10, 13, 0, 0, 0, 0, time.Local)}) {
+		t.Fatal("event on the next day included")
+	}
+	if got, _ := ParseUntil("2026-10-12 08:30", now); !got.Equal(time.Date(2026, 10, 12, 8, 31, 0, 0, time.Local).Add(-time.Nanosecond)) {
+		t.Fatalf("minute bound = %v", got)
+	}
+	if got, _ := ParseUntil("24h", now); !got.Equal(now.Add(-24 * time.Hour)) {
+		t.Fatalf("relative bound = %v", got)
+	}
+}
@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Un journal tourne garde le nom du journal suivi de la date de sa derniere
+// entree : audit.log -> audit-20261019-150405.000000000.log(.gz)
+const rotatedLayout = "20060102-150405.000000000"
+
+type rotatedFile struct {
+	path  string
+	stamp time.Time
+}
+
+func splitName(path string) (dir, stem, ext string) {
+	dir, base := filepath.Split(path)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext), ext
+}
+
+func rotatedName(path string, stamp time.Time) string {
+	dir, stem, ext := splitName(path)
+	return filepath.Join(dir, stem+"-"+stamp.Format(rotatedLayout)+ext)
+}
+
+// rotatedFiles liste les journaux tournes du plus ancien au plus recent
+func rotatedFiles(path string) ([]rotatedFile, error) {
+	dir, stem, ext := splitName(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []rotatedFile
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".gz")
+		if e.IsDir() || !strings.HasPrefix(name, stem+"-") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, stem+"-"), ext)
+		stamp, err := time.ParseInLocation(rotatedLayout, ts, time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, rotatedFile{path: filepath.Join(dir, e.Name()), stamp: stamp})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].stamp.Before(files[j].stamp) })
+	return files, nil
+}
+
+// Files renvoie tous les fichiers du journal dans l'ordre d'ecriture :
+// les journaux tournes (eventuellement .gz) puis le journal courant
+func Files(path string) ([]string, error) {
+	rotated, err := rotatedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, r := range rotated {
+		files = append(files, r.path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// openLog ouvre un fichier du journal en decompressant les .gz
+func openLog(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{zr, f}, nil
+}
+
+// needsRotation decide avant une ecriture si le journal courant doit etre tourne.
+// La date de modification suffit pour la rotation periodique : chaque ecriture
+// dans une nouvelle periode tourne d'abord le fichier, qui ne contient donc
+// que des entrees de la periode de sa derniere modification.
+func needsRotation(path string, now time.Time) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+	if settings.MaxSizeMB > 0 && info.Size() >= int64(settings.MaxSizeMB)*1024*1024 {
+		return true
+	}
+	mod := info.ModTime()
+	switch settings.RotateEvery {
+	case "hourly":
+		return !mod.Truncate(time.Hour).Equal(now.Truncate(time.Hour))
+	case "daily":
+		y1, m1, d1 := mod.Date()
+		y2, m2, d2 := now.Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+// rotate renomme le journal courant, le compresse puis applique la retention.
+// Doit etre appele verrou pris.
+func rotate(path string, now time.Time) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stamp := info.ModTime()
+	dest := rotatedName(path, stamp)
+	for exists(dest) || exists(dest+".gz") {
+		stamp = stamp.Add(time.Nanosecond)
+		dest = rotatedName(path, stamp)
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return fmt.Errorf("rotation du journal: %w", err)
+	}
+	if settings.Compress {
+		if err := gzipFile(dest); err != nil {
+			return fmt.Errorf("compression de %s: %w", dest, err)
+		}
+	}
+	return applyRetention(path, now)
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	in.Close()
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// applyRetention supprime les journaux tournes en trop ou trop anciens
+func applyRetention(path string, now time.Time) error {
+	files, err := rotatedFiles(path)
+	if err != nil {
+		return err
+	}
+	keep := files
+	if settings.MaxFiles > 0 && len(keep) > settings.MaxFiles {
+		keep = keep[len(keep)-settings.MaxFiles:]
+	}
+	if settings.MaxAgeDays > 0 {
+		limit := now.AddDate(0, 0, -settings.MaxAgeDays)
+		for len(keep) > 0 && keep[0].stamp.Before(limit) {
+			keep = keep[1:]
+		}
+	}
+	for _, f := range files[:len(files)-len(keep)] {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backdate simule un journal ecrit la veille
+func backdate(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	old := time.Now().Add(-d)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestDailyRotationKeepsChain(t *testing.T) {
+	old := settings
+	t.Cleanup(func() { settings = old })
+	settings.RotateEvery = "daily"
+	settings.Compress = true
+
+	outDir := t.TempDir()
+	path := filepath.Join(outDir, "audit.log")
+	for day := 3; day >= 1; day-- {
+		Start(ActionLock, "f").Finish(outDir, OutcomeSuccess, nil)
+		backdate(t, path, time.Duration(day)*24*time.Hour)
+	}
+	Start(ActionUnlock, "f").Finish(outDir, OutcomeSuccess, nil)
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatalf("files: %v", err)
+	}
+	if len(files) != 4 || files[3] != path {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	for _, f := range files[:3] {
+		if !strings.HasSuffix(f, ".log.gz") {
+			t.Fatalf("rotated file not compressed: %s", f)
+		}
+	}
+
+	rep, err := Verify(path, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if rep.Problem != nil || rep.Entries != 4 || rep.Files != 4 {
+		t.Fatalf("unexpected report: %+v (problem %v)", rep, rep.Problem)
+	}
+}
+
+func TestRetentionMaxFiles(t *testing.T) {
+	old := settings
+	t.Cleanup(func() { settings = old })
+	settings.RotateEvery = "daily"
+	settings.Compress = false
+	settings.MaxFiles = 1
+
+	outDir := t.TempDir()
+	path := filepath.Join(outDir, "audit.log")
+	for day := 3; day >= 1; day-- {
+		Start(ActionLock, "f").Finish(outDir, OutcomeSuccess, nil)
+		backdate(t, path, time.Duration(day)*24*time.Hour)
+	}
+	Start(ActionUnlock, "f").Finish(outDir, OutcomeSuccess, nil)
+
+	rotated, err := rotatedFiles(path)
+	if err != nil {
+		t.Fatalf("rotated: %v", err)
+	}
+	if len(rotated) != 1 {
+		t.Fatalf("expected 1 rotated file kept, got %d", len(rotated))
+	}
+
+	rep, err := Verify(path, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if rep.Problem != nil || rep.FirstSeq != 3 {
+		t.Fatalf("unexpected report: %+v (problem %v)", rep, rep.Problem)
+	}
+}
+
+func TestRetentionExpiresAllRotated(t *testing.T) {
+	old := settings
+	t.Cleanup(func() { settings = old })
+	settings.RotateEvery = "daily"
+	settings.Compress = false
+	settings.MaxAgeDays = 1
+
+	outDir := t.TempDir()
+	path := filepath.Join(outDir, "audit.log")
+	for i := 0; i < 3; i++ {
+		Start(ActionLock, "f").Finish(outDir, OutcomeSuccess, nil)
+		backdate(t, path, 5*24*time.Hour)
+	}
+	Start(ActionUnlock, "f").Finish(outDir, OutcomeSuccess, nil)
+
+	files, err := Files(path)
+	if err != nil {
+		t.Fatalf("files: %v", err)
+	}
+	if len(files) != 1 || files[0] != path {
+		t.Fatalf("expected only the current log, got %v", files)
+	}
+
+	rep, err := Verify(path, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if rep.Problem != nil || rep.FirstSeq != 4 || rep.Entries != 1 {
+		t.Fatalf("unexpected report: %+v (problem %v)", rep, rep.Problem)
+	}
+}
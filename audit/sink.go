@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"gotools/config"
+)
+
+// Sink est une destination supplementaire du journal d'audit (audit.sinks).
+// Le journal local reste la reference : il chaine les entrees, les sinks
+// recoivent la ligne deja chainee.
+type Sink interface {
+	// Write transmet un evenement ; outDir sert de base aux chemins relatifs
+	Write(outDir string, e *Event, line []byte) error
+	Close() error
+}
+
+var (
+	sinksMu   sync.Mutex
+	sinks     []Sink
+	sinksConf []config.SinkConfig
+)
+
+// NewSink cree la destination decrite par c
+func NewSink(c config.SinkConfig) (Sink, error) {
+	timeout := time.Duration(c.TimeoutSec) * time.Second
+	switch c.Type {
+	case "file":
+		return &fileSink{path: c.Path}, nil
+	case "syslog":
+		return &syslogSink{network: c.Network, address: c.Address, timeout: timeout}, nil
+	case "webhook":
+		return newWebhookSink(c.URL, c.Token, c.Retries, timeout, c.BufferFile), nil
+	default:
+		return nil, fmt.Errorf("type de sink inconnu %q", c.Type)
+	}
+}
+
+func configureSinks(conf []config.SinkConfig) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if reflect.DeepEqual(conf, sinksConf) {
+		return
+	}
+	closeSinksLocked()
+	for _, c := range conf {
+		s, err := NewSink(c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur audit sink: %v\n", err)
+			continue
+		}
+		sinks = append(sinks, s)
+	}
+	sinksConf = conf
+}
+
+// forward envoie l'evenement a toutes les destinations, les erreurs sont cumulees
+func forward(outDir string, e *Event, line []byte) error {
+	sinksMu.Lock()
+	current := sinks
+	sinksMu.Unlock()
+
+	var errs []error
+	for _, s := range current {
+		if err := s.Write(outDir, e, line); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeSinks ferme les destinations (connexions syslog...), voir Close
+func closeSinks() error {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	err := closeSinksLocked()
+	sinksConf = nil
+	return err
+}
+
+func closeSinksLocked() error {
+	var errs []error
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sinks = nil
+	return errors.Join(errs...)
+}
+
+// resolvePath rend un chemin relatif a outDir
+func resolvePath(outDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(outDir, path)
+}
+
+// fileSink recopie les lignes dans un second fichier (disque partage, autre volume...)
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *fileSink) Write(outDir string, _ *Event, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := resolvePath(outDir, s.path)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("sink file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("sink file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error { return nil }
@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gotools/config"
+)
+
+func withSinks(t *testing.T, conf ...config.SinkConfig) {
+	t.Helper()
+	configureSinks(conf)
+	t.Cleanup(func() { Close() })
+}
+
+func TestFileSinkCopiesLines(t *testing.T) {
+	outDir := t.TempDir()
+	withSinks(t, config.SinkConfig{Type: "file", Path: "copy.log"})
+
+	Start(ActionKill, "42").Finish(outDir, OutcomeSuccess, nil)
+	Start(ActionKill, "43").Finish(outDir, OutcomeSuccess, nil)
+
+	local, _ := os.ReadFile(filepath.Join(outDir, "audit.log"))
+	copied, err := os.ReadFile(filepath.Join(outDir, "copy.log"))
+	if err != nil {
+		t.Fatalf("read copy: %v", err)
+	}
+	if string(local) != string(copied) {
+		t.Fatalf("copy differs from journal:\n%s\n---\n%s", local, copied)
+	}
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp unavailable: %v", err)
+	}
+	defer pc.Close()
+	withSinks(t, config.SinkConfig{Type: "syslog", Network: "udp", Address: pc.LocalAddr().String(), TimeoutSec: 2})
+
+	Start(ActionChmod, `data/"x"].txt`).Finish(t.TempDir(), OutcomeFailure, os.ErrPermission)
+
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no syslog message: %v", err)
+	}
+	msg := string(buf[:n])
+	// authpriv (10) * 8 + warning (4)
+	if !strings.HasPrefix(msg, "<84>1 ") {
+		t.Fatalf("bad header: %q", msg)
+	}
+	if !strings.Contains(msg, ` gotools `) || !strings.Contains(msg, ` CHMOD [gotools@32473 outcome="failure" target="data/\"x\"\].txt" seq="1"]`) {
+		t.Fatalf("bad structured data: %q", msg)
+	}
+	if !strings.Contains(msg, "\ufeff{") {
+		t.Fatalf("missing json message: %q", msg)
+	}
+}
+
+func TestSyslogFrameTCP(t *testing.T) {
+	s := &syslogSink{network: "tcp"}
+	if got := string(s.frame("<85>1 abc")); got != "9 <85>1 abc" {
+		t.Fatalf("frame = %q", got)
+	}
+}
+
+func TestWebhookSinkBuffersAndReplays(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer srv.Close()
+
+	outDir := t.TempDir()
+	s := newWebhookSink(srv.URL, "s3cret", 2, time.Second, "hook.buffer")
+	s.backoff = time.Millisecond
+
+	s.enqueue(outDir, []byte(`{"seq":1}`))
+	if err := s.flush(); err == nil {
+		t.Fatal("expected error while server fails")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "hook.buffer")); err != nil {
+		t.Fatalf("event not buffered: %v", err)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	s.enqueue(outDir, []byte(`{"seq":2}`))
+	if err := s.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(received) != 2 || received[0] != `{"seq":1}` || received[1] != `{"seq":2}` {
+		t.Fatalf("events not replayed in order: %q", received)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "hook.buffer")); !os.IsNotExist(err) {
+		t.Fatal("buffer should be removed once flushed")
+	}
+}
+
+func TestWebhookSinkNoRetryOnClientError(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s := newWebhookSink(srv.URL, "", 3, time.Second, "hook.buffer")
+	s.backoff = time.Millisecond
+	s.enqueue(t.TempDir(), []byte(`{}`))
+	s.flush()
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestWebhookSinkRejectedEventDoesNotBlock(t *testing.T) {
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "bad") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, string(body))
+	}))
+	defer srv.Close()
+
+	outDir := t.TempDir()
+	s := newWebhookSink(srv.URL, "", 3, time.Second, "hook.buffer")
+	s.enqueue(outDir, []byte(`{"bad":1}`))
+	s.enqueue(outDir, []byte(`{"seq":2}`))
+	if err := s.flush(); err == nil || !strings.Contains(err.Error(), "refuse") {
+		t.Fatalf("expected rejection error, got %v", err)
+	}
+	if len(received) != 1 || received[0] != `{"seq":2}` {
+		t.Fatalf("event after a rejected one not delivered: %q", received)
+	}
+	dead, err := os.ReadFile(filepath.Join(outDir, "hook.buffer.rejected"))
+	if err != nil || string(dead) != "{\"bad\":1}\n" {
+		t.Fatalf("rejected event not dead-lettered: %q, %v", dead, err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "hook.buffer")); !os.IsNotExist(err) {
+		t.Fatal("rejected event should leave the buffer")
+	}
+}
+
+func TestWebhookSinkWriteDoesNotWait(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	outDir := t.TempDir()
+	s := newWebhookSink(srv.URL, "", 3, 10*time.Second, "hook.buffer")
+	begin := time.Now()
+	if err := s.Write(outDir, nil, []byte(`{"seq":1}`)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if d := time.Since(begin); d > 2*time.Second {
+		t.Fatalf("write and close blocked for %v", d)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "hook.buffer")); err != nil {
+		t.Fatalf("undelivered event should stay buffered: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	facilityAuthPriv = 10
+	severityWarning  = 4
+	severityNotice   = 5
+	// numero d'entreprise reserve a la documentation (RFC 5612)
+	sdID = "gotools@32473"
+)
+
+// syslogSink envoie les evenements au format RFC 5424 en UDP, TCP ou socket unix
+type syslogSink struct {
+	mu      sync.Mutex
+	network string
+	address string
+	timeout time.Duration
+	conn    net.Conn
+}
+
+func (s *syslogSink) Write(_ string, e *Event, line []byte) error {
+	msg := formatRFC5424(e, line)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// une connexion coupee (serveur redemarre) est retentee une fois
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				return fmt.Errorf("sink syslog %s: %w", s.address, err)
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		if _, err = s.conn.Write(s.frame(msg)); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("sink syslog %s: %w", s.address, err)
+}
+
+func (s *syslogSink) dial() (net.Conn, error) {
+	if s.network == "unix" {
+		// /dev/log est un socket datagramme, sinon on tente le mode flux
+		if c, err := net.DialTimeout("unixgram", s.address, s.timeout); err == nil {
+			return c, nil
+		}
+	}
+	return net.DialTimeout(s.network, s.address, s.timeout)
+}
+
+// frame ajoute la longueur du message en TCP (octet counting, RFC 6587)
+func (s *syslogSink) frame(msg string) []byte {
+	if s.network == "tcp" {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	}
+	return []byte(msg)
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// formatRFC5424 : <PRI>1 TIMESTAMP HOST APP PROCID MSGID [SD] MSG
+func formatRFC5424(e *Event, line []byte) string {
+	severity := severityNotice
+	if e.Outcome != OutcomeSuccess {
+		severity = severityWarning
+	}
+	host := e.Host
+	if host == "" {
+		host = "-"
+	}
+	sd := fmt.Sprintf(`[%s outcome="%s" target="%s" seq="%d"]`,
+		sdID, sdEscape(string(e.Outcome)), sdEscape(e.Target), e.Seq)
+	return fmt.Sprintf("<%d>1 %s %s gotools %d %s %s \ufeff%s",
+		facilityAuthPriv*8+severity,
+		e.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		host, e.PID, e.Action, sd, line)
+}
+
+// sdEscape echappe les caracteres interdits dans une valeur SD-PARAM (RFC 5424 6.3.3)
+func sdEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// webhookSink poste chaque evenement en JSON depuis une goroutine : Write ajoute
+// la ligne au fichier tampon et rend la main aussitot, l'action auditee
+// n'attend jamais le serveur. Les lignes partent dans l'ordre ; si le serveur
+// reste injoignable apres les tentatives, elles restent dans le tampon et
+// repartent au prochain evenement (ou au prochain lancement). Une ligne refusee
+// (4xx) est deplacee dans <tampon>.rejected pour ne pas bloquer les suivantes.
+type webhookSink struct {
+	mu      sync.Mutex // fichier tampon, path et lastErr
+	url     string
+	token   string
+	retries int
+	buffer  string
+	path    string // tampon resolu par rapport a out_dir
+	lastErr error  // erreur d'envoi en arriere-plan, rendue par le Write suivant
+	client  *http.Client
+	backoff time.Duration
+
+	ctx    context.Context // annule par Close, interrompt l'envoi en cours
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+	start  sync.Once
+}
+
+func newWebhookSink(url, token string, retries int, timeout time.Duration, buffer string) *webhookSink {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookSink{
+		url:     url,
+		token:   token,
+		retries: retries,
+		buffer:  buffer,
+		client:  &http.Client{Timeout: timeout},
+		backoff: 500 * time.Millisecond,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *webhookSink) Write(outDir string, _ *Event, line []byte) error {
+	if err := s.enqueue(outDir, line); err != nil {
+		return err
+	}
+	s.start.Do(func() { go s.run() })
+	select {
+	case s.wake <- struct{}{}:
+	default: // un envoi est deja demande
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.lastErr
+	s.lastErr = nil
+	return err
+}
+
+// enqueue ajoute la ligne au tampon, d'ou la goroutine d'envoi la retire
+func (s *webhookSink) enqueue(outDir string, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = resolvePath(outDir, s.buffer)
+	if err := appendLines(s.path, [][]byte{line}); err != nil {
+		return fmt.Errorf("sink webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *webhookSink) run() {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		}
+		if err := s.flush(); err != nil {
+			s.mu.Lock()
+			s.lastErr = err
+			s.mu.Unlock()
+		}
+	}
+}
+
+// flush envoie les lignes du tampon dans l'ordre et retire celles qui sont
+// livrees ou refusees ; elle s'arrete a la premiere panne du serveur. Le
+// verrou n'est pas tenu pendant l'envoi : Write peut ajouter des lignes.
+func (s *webhookSink) flush() error {
+	s.mu.Lock()
+	path := s.path
+	pending, err := readBuffer(path)
+	s.mu.Unlock()
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	sent := 0
+	var rejected [][]byte
+	var rejectErr, downErr error
+	for _, p := range pending {
+		retry, err := s.post(p)
+		if err != nil && retry {
+			downErr = err
+			break
+		}
+		if err != nil {
+			rejected = append(rejected, p)
+			rejectErr = err
+		}
+		sent++
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	if len(rejected) > 0 {
+		dead := path + ".rejected"
+		if err := appendLines(dead, rejected); err != nil {
+			return fmt.Errorf("sink webhook: %v (rejet: %w)", rejectErr, err)
+		}
+		errs = append(errs, fmt.Errorf("sink webhook: %d evenement(s) refuse(s) (%w), copie(s) dans %s", len(rejected), rejectErr, dead))
+	}
+	// les lignes ajoutees entre-temps suivent celles deja traitees
+	rest, err := readBuffer(path)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	rest = rest[min(sent, len(rest)):]
+	if len(rest) == 0 {
+		err = os.Remove(path)
+	} else {
+		err = writeBuffer(path, rest)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Errorf("sink webhook: tampon: %w", err))
+	}
+	if downErr != nil {
+		errs = append(errs, fmt.Errorf("sink webhook: %w (%d evenement(s) en attente dans %s)", downErr, len(rest), path))
+	}
+	return errors.Join(errs...)
+}
+
+// post envoie une ligne, en reessayant sur erreur reseau, 429 et 5xx ; retry
+// indique une panne du serveur (la ligne reste a envoyer)
+func (s *webhookSink) post(body []byte) (retry bool, err error) {
+	for attempt := 0; attempt < s.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(s.backoff << (attempt - 1)):
+			case <-s.ctx.Done():
+				return true, s.ctx.Err()
+			}
+		}
+		if retry, err = s.send(body); err == nil || !retry {
+			return retry, err
+		}
+	}
+	return true, err
+}
+
+func (s *webhookSink) send(body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotools-audit")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("HTTP %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+}
+
+// Close arrete l'envoi sans attendre le serveur ; ce qui n'est pas parti reste
+// dans le tampon
+func (s *webhookSink) Close() error {
+	s.cancel()
+	s.start.Do(func() { close(s.done) }) // goroutine jamais lancee
+	<-s.done
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func readBuffer(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sink webhook: %w", err)
+	}
+	defer f.Close()
+	var lines [][]byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) > 0 {
+			lines = append(lines, append([]byte(nil), sc.Bytes()...))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("sink webhook: lecture %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+func appendLines(path string, lines [][]byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	data := append(bytes.Join(lines, []byte("\n")), '\n')
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeBuffer remplace le tampon de facon atomique
+func writeBuffer(path string, lines [][]byte) error {
+	tmp := path + ".tmp"
+	data := append(bytes.Join(lines, []byte("\n")), '\n')
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
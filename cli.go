@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gotools/audit"
+	"gotools/config"
+	"gotools/fileops"
+)
+
+// runCommand execute une sous-commande et renvoie le code de sortie
+func runCommand(args []string) int {
+	switch args[0] {
+	case "config":
+		return runConfigCommand(args[1:])
+	case "audit":
+		return runAuditCommand(args[1:])
+	case "tail":
+		return runTail(args[1:])
+	case "logs":
+		return runLogs(args[1:])
+	case "incidents":
+		return runIncidents(args[1:])
+	case "diff":
+		return runDiff(args[1:])
+	case "uniq":
+		return runUniq(args[1:])
+	case "ngrams":
+		return runNgrams(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Commande inconnue: %s\n", args[0])
+		printUsage()
+		return 2
+	}
+}
+
+func runConfigCommand(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+	switch args[0] {
+	case "show":
+		// MarshalJSON masque les secrets resolus
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	case "schema":
+		if err := config.WriteSchema(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			return 1
+		}
+		return 0
+	case "doc":
+		if err := config.WriteDoc(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Commande inconnue: config %s\n", args[0])
+		printUsage()
+		return 2
+	}
+}
+
+func runAuditCommand(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return 2
+	}
+	switch args[0] {
+	case "verify":
+		return runAuditVerify(args[1:])
+	case "query":
+		return runAuditQuery(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Commande inconnue: audit %s\n", args[0])
+		printUsage()
+		return 2
+	}
+}
+
+func runAuditQuery(args []string) int {
+	fs := flag.NewFlagSet("audit query", flag.ContinueOnError)
+	since := fs.String("since", "", "depuis (2026-10-12, \"2026-10-12 08:00\", 24h, 7d)")
+	until := fs.String("until", "", "jusqu'a, inclus (meme format que --since ; une date seule couvre la journee)")
+	actions := fs.String("action", "", "actions separees par des virgules (KILL,LOCK,UNLOCK,CHMOD)")
+	target := fs.String("target", "", "PID exact ou partie du chemin")
+	outcomes := fs.String("outcome", "", "resultats separes par des virgules (success,failure,cancelled)")
+	last := fs.Int("last", 0, "garde les N evenements les plus recents")
+	format := fs.String("format", cfg.Audit.QueryFormat, "table, json ou csv")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	f := audit.Filter{Target: *target, Last: *last}
+	now := time.Now()
+	var err error
+	if *since != "" {
+		if f.Since, err = audit.ParseTime(*since, now); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur --since: %v\n", err)
+			return 2
+		}
+	}
+	if *until != "" {
+		if f.Until, err = audit.ParseUntil(*until, now); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur --until: %v\n", err)
+			return 2
+		}
+	}
+	for _, a := range splitList(*actions) {
+		f.Actions = append(f.Actions, audit.Action(strings.ToUpper(a)))
+	}
+	for _, o := range splitList(*outcomes) {
+		f.Outcomes = append(f.Outcomes, audit.Outcome(strings.ToLower(o)))
+	}
+
+	events, err := audit.Query(filepath.Join(cfg.OutDir, cfg.Audit.File), f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	if err := audit.WriteEvents(os.Stdout, events, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func runAuditVerify(args []string) int {
+	path := filepath.Join(cfg.OutDir, cfg.Audit.File)
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	rep, err := audit.Verify(path, cfg.Audit.HMACKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	if rep.FirstSeq > 1 {
+		fmt.Printf("La chaine commence a seq %d (journaux plus anciens supprimes par la retention)\n", rep.FirstSeq)
+	}
+	if rep.Legacy > 0 {
+		fmt.Printf("%d ligne(s) ancienne(s) non chainee(s) en debut de journal\n", rep.Legacy)
+	}
+	if rep.Problem != nil {
+		fmt.Println(failure(rep.Problem.String()))
+		return 1
+	}
+	fmt.Println(success(fmt.Sprintf("%d entree(s) verifiee(s) dans %d fichier(s), chaine intacte", rep.Entries, rep.Files)))
+	if rep.Entries > 0 {
+		fmt.Printf("Dernier maillon : seq %d, hash %s\n", rep.LastSeq, rep.LastHash)
+	}
+	return 0
+}
+
+// runTail affiche les dernieres lignes des fichiers, puis les suit avec -f
+// jusqu'a Ctrl+C
+func runTail(args []string) int {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	follow := fs.Bool("f", false, "suit les lignes ajoutees (rotation comprise)")
+	n := fs.Int("n", 10, "dernieres lignes affichees au depart")
+	expr := fs.String("filter", "", "filtre (ex: \"error AND NOT debug\")")
+	regex := fs.Bool("regex", false, "motifs = expressions regulieres")
+	caseSensitive := fs.Bool("case", false, "respecte la casse")
+	word := fs.Bool("word", false, "mot entier")
+	before := fs.Int("B", 0, "lignes de contexte avant")
+	after := fs.Int("A", 0, "lignes de contexte apres")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		printUsage()
+		return 2
+	}
+
+	o := fileops.FollowOptions{
+		Filter: fileops.FilterOptions{Expr: *expr, Regex: *regex, CaseSensitive: *caseSensitive,
+			WholeWord: *word, Before: *before, After: *after, Color: useColor()},
+		Lines: max(*n, 0),
+		Once:  !*follow,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := fileops.Follow(ctx, fs.Args(), o, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runLogs analyse un fichier de log ; les CSV sont ecrits dans out_dir
+func runLogs(args []string) int {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	format := fs.String("format", cfg.FileOps.LogFormat, "auto, json, logfmt, syslog, combined ou un format de fileops.log_formats")
+	top := fs.Int("top", cfg.FileOps.TopValues, "valeurs affichees par champ")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		printUsage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	st, err := fileops.AnalyzeLog(path, *format, run.Dir)
+	if err := errors.Join(err, run.End()); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	fileops.PrintLogStats(st, *top)
+	return 0
+}
+
+// runIncidents signale les pics d'erreurs d'un log ; le rapport est ecrit dans out_dir
+func runIncidents(args []string) int {
+	fs := flag.NewFlagSet("incidents", flag.ContinueOnError)
+	format := fs.String("format", cfg.FileOps.LogFormat, "auto, json, logfmt, syslog, combined ou un format de fileops.log_formats")
+	bucket := fs.String("bucket", cfg.FileOps.IncidentBucket, "taille des tranches : minute ou hour")
+	level := fs.String("level", "ERROR", "niveau minimal compte comme erreur")
+	expr := fs.String("filter", "", "compte les lignes qui correspondent a ce filtre au lieu du niveau")
+	regex := fs.Bool("regex", false, "motifs du filtre = expressions regulieres")
+	threshold := fs.Float64("threshold", cfg.FileOps.IncidentThreshold, "ecart au taux de reference pour signaler un pic")
+	minErrors := fs.Int("min-errors", cfg.FileOps.IncidentMinErrors, "erreurs minimum dans une tranche")
+	samples := fs.Int("samples", cfg.FileOps.IncidentSamples, "lignes d'exemple par incident")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		printUsage()
+		return 2
+	}
+	d, err := fileops.ParseBucket(*bucket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 2
+	}
+
+	path := fs.Arg(0)
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	rep, err := fileops.DetectIncidents(path, fileops.IncidentOptions{
+		Format: *format, Bucket: d, MinLevel: *level,
+		Filter:    fileops.FilterOptions{Expr: *expr, Regex: *regex},
+		Threshold: *threshold, MinHits: *minErrors, Samples: *samples,
+	}, run.Dir)
+	if err := errors.Join(err, run.End()); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	fileops.PrintIncidentReport(rep)
+	return 0
+}
+
+// runDiff compare deux fichiers ligne a ligne, ou deux rapports avec --reports
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	reports := fs.Bool("reports", false, "compare deux report.txt (taille, lignes, mots par fichier)")
+	side := fs.Bool("side-by-side", false, "affichage en deux colonnes")
+	ignoreSpace := fs.Bool("ignore-space", false, "ignore les differences d'espaces")
+	ignoreCase := fs.Bool("ignore-case", false, "ignore la casse")
+	context := fs.Int("U", 3, "lignes de contexte")
+	width := fs.Int("width", 130, "largeur de l'affichage cote a cote")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		printUsage()
+		return 2
+	}
+
+	a, b := fs.Arg(0), fs.Arg(1)
+	run, err := fileops.BeginRun(cfg.OutDir, b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 2
+	}
+	if *reports {
+		d, err := fileops.DiffReports(a, b, run.Dir)
+		if err := errors.Join(err, run.End()); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			return 2
+		}
+		fileops.PrintReportDiff(d)
+		return 0
+	}
+	res, err := fileops.DiffFiles(a, b, fileops.DiffOptions{
+		IgnoreSpace: *ignoreSpace, IgnoreCase: *ignoreCase, Context: *context,
+		SideBySide: *side, Width: *width, Color: useColor(),
+	}, run.Dir)
+	if err := errors.Join(err, run.End()); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 2
+	}
+	fileops.PrintDiff(res)
+	if !res.Identical() {
+		return 1 // comme diff : 1 si les fichiers different, 2 en cas d'erreur
+	}
+	return 0
+}
+
+// runUniq compte les lignes en double et ecrit une copie sans doublons dans out_dir
+func runUniq(args []string) int {
+	fs := flag.NewFlagSet("uniq", flag.ContinueOnError)
+	normalize := fs.Bool("normalize", false, "regroupe les lignes qui ne different que par nombres, dates, UUID, IP...")
+	ignoreCase := fs.Bool("ignore-case", false, "ignore la casse")
+	top := fs.Int("top", cfg.FileOps.TopValues, "lignes repetees affichees")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		printUsage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	res, err := fileops.FindDuplicates(path, fileops.DedupOptions{Normalize: *normalize, IgnoreCase: *ignoreCase}, run.Dir)
+	if err := errors.Join(err, run.End()); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	fileops.PrintDuplicates(res, *top)
+	return 0
+}
+
+// runNgrams extrait bigrammes, trigrammes et collocations d'un fichier ou d'un dossier
+func runNgrams(args []string) int {
+	fs := flag.NewFlagSet("ngrams", flag.ContinueOnError)
+	minCount := fs.Int("min", cfg.FileOps.NgramMinCount, "occurrences minimum d'un n-gramme")
+	lang := fs.String("lang", "", "mots vides ignores : fr, en ou none (defaut fileops.language)")
+	top := fs.Int("top", cfg.FileOps.TopValues, "lignes affichees par classement")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		printUsage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	res, err := fileops.NgramReport(path, fileops.NgramOptions{Language: *lang, MinCount: *minCount}, run.Dir)
+	if err := errors.Join(err, run.End()); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 1
+	}
+	fileops.PrintNgrams(res, *top)
+	return 0
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  gotools [--config fichier]              menu interactif")
+	fmt.Fprintln(os.Stderr, "  gotools [--config fichier] config show  config effective (secrets masques)")
+	fmt.Fprintln(os.Stderr, "  gotools [--config fichier] config doc   documentation des cles de config")
+	fmt.Fprintln(os.Stderr, "  gotools config schema                   JSON Schema de config.json")
+	fmt.Fprintln(os.Stderr, "  gotools [--config fichier] audit verify [journal]")
+	fmt.Fprintln(os.Stderr, "                                          verifie le chainage du journal d'audit")
+	fmt.Fprintln(os.Stderr, "  gotools [--config fichier] audit query [--since 7d] [--until date] [--action KILL,...]")
+	fmt.Fprintln(os.Stderr, "          [--target chemin|PID] [--outcome success,...] [--last N] [--format table|json|csv]")
+	fmt.Fprintln(os.Stderr, "  gotools tail [-f] [-n 10] [--filter expr] [--regex] [--case] [--word] [-B N] [-A N] fichier...")
+	fmt.Fprintln(os.Stderr, "                                          dernieres lignes, suivies avec -f (rotation comprise)")
+	fmt.Fprintln(os.Stderr, "  gotools logs [--format auto|json|logfmt|syslog|combined|nom] [--top N] fichier")
+	fmt.Fprintln(os.Stderr, "                                          niveaux, codes HTTP, champs et chronologie d'un log")
+	fmt.Fprintln(os.Stderr, "  gotools incidents [--format ...] [--bucket minute|hour] [--level ERROR] [--filter expr] [--regex]")
+	fmt.Fprintln(os.Stderr, "          [--threshold 3] [--min-errors 5] [--samples 3] fichier")
+	fmt.Fprintln(os.Stderr, "                                          pics du taux d'erreurs, rapport d'incidents")
+	fmt.Fprintln(os.Stderr, "  gotools diff [--side-by-side] [--ignore-space] [--ignore-case] [-U 3] [--width N] fichierA fichierB")
+	fmt.Fprintln(os.Stderr, "  gotools diff --reports reportA.txt reportB.txt")
+	fmt.Fprintln(os.Stderr, "                                          differences ligne a ligne, ou entre deux rapports du menu B")
+	fmt.Fprintln(os.Stderr, "  gotools uniq [--normalize] [--ignore-case] [--top N] fichier")
+	fmt.Fprintln(os.Stderr, "                                          lignes en double (uniq -c) et copie sans doublons")
+	fmt.Fprintln(os.Stderr, "  gotools ngrams [--min 3] [--lang fr|en|none] [--top N] fichier|dossier")
+	fmt.Fprintln(os.Stderr, "                                          bigrammes, trigrammes et collocations (PMI)")
+}
@@ -5,36 +5,148 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
+	"regexp"
 	"strings"
 )
 
+// Les tags decrivent chaque cle : default (valeur par defaut), desc (doc),
+// min/max (bornes numeriques) et enum (valeurs autorisees, separees par des virgules).
 type Config struct {
-	DefaultFile string `json:"default_file"`
-	BaseDir     string `json:"base_dir"`
-	OutDir      string `json:"out_dir"`
-	DefaultExt  string `json:"default_ext"`
-	WikiLang    string `json:"wiki_lang"`
-	ProcessTopN int    `json:"process_top_n"`
+	DefaultFile string `json:"default_file" default:"data/input.txt" desc:"Fichier propose par defaut (menus A et E)"`
+	BaseDir     string `json:"base_dir" default:"data" desc:"Dossier propose par defaut (menus B et H)"`
+	OutDir      string `json:"out_dir" default:"out" desc:"Dossier des fichiers generes" required:"true"`
+	DefaultExt  string `json:"default_ext" default:".txt" desc:"Extension par defaut des fichiers texte"`
+	WikiLang    string `json:"wiki_lang" default:"fr" enum:"fr,en,de,es,it,pt,nl" desc:"Langue de Wikipedia (menu C)"`
+	ProcessTopN int    `json:"process_top_n" default:"10" min:"0" desc:"Nombre de processus affiches (0 = tous)"`
+
+	FileOps   FileOpsConfig   `json:"fileops" desc:"Options de FileOps (menus A, B et H)"`
+	WebOps    WebOpsConfig    `json:"webops" desc:"Options de WebOps (menu C)"`
+	ProcOps   ProcOpsConfig   `json:"procops" desc:"Options de ProcOps (menu D)"`
+	SecureOps SecureOpsConfig `json:"secureops" desc:"Options de SecureOps (menu E)"`
+	InfraOps  InfraOpsConfig  `json:"infraops" desc:"Options d'InfraOps (menus F et G)"`
+	Audit     AuditConfig     `json:"audit" desc:"Journal des actions sensibles"`
+
+	// cles dont la valeur vient d'une reference secrete (env:, file:, cmd:)
+	secrets map[string]bool
+}
+
+type FileOpsConfig struct {
+	Extensions []string `json:"extensions" default:".txt" desc:"Extensions des fichiers traites dans un dossier"`
+	Encoding   string   `json:"encoding" default:"auto" enum:"auto,utf-8,utf-16le,utf-16be,latin-1,windows-1252" desc:"Encodage des fichiers lus (auto = BOM puis detection), remplace par --encoding"`
+
+	Language string `json:"language" default:"auto" enum:"auto,fr,en,none" desc:"Langue des mots vides ignores par la frequence des mots (auto = wiki_lang)"`
+	TopWords int    `json:"top_words" default:"10" min:"1" max:"1000" desc:"Nombre de mots les plus frequents affiches"`
+	Stemming bool   `json:"stemming" default:"false" desc:"Regroupe les formes d'un mot (racinisation legere : pluriels, suffixes courants)"`
+
+	NgramMinCount int `json:"ngram_min_count" default:"3" min:"1" desc:"Occurrences minimum d'un bigramme ou trigramme retenu (n-grammes et collocations, menu O)"`
+
+	OutputName string `json:"output_name" default:"{op}{ext}" required:"true" desc:"Modele de nom des fichiers generes : {base} (fichier ou dossier analyse), {op} (head, filtered, report...), {ext}, {timestamp}, {date}"`
+	RunDir     string `json:"run_dir" default:"" desc:"Sous-dossier de out_dir par execution (ex: {base}_{timestamp}), vide = directement dans out_dir"`
+	Overwrite  string `json:"overwrite" default:"suffix" enum:"overwrite,suffix,fail" desc:"Fichier de sortie deja present : suffix (ajoute _1, _2...), overwrite (remplace) ou fail (erreur)"`
+	Manifest   bool   `json:"manifest" default:"true" desc:"Ajoute la liste des fichiers produits par chaque execution a out_dir/manifest.jsonl"`
+
+	CompressOutput string `json:"compress_output" default:"none" enum:"none,gzip" desc:"Compresse les fichiers generes (rapports, filtres, fusion) : none ou gzip (.gz ajoute)"`
+
+	LogFormat  string            `json:"log_format" default:"auto" desc:"Format des logs (menu K) : auto, json, logfmt, syslog, combined ou le nom d'un format de log_formats"`
+	LogFormats []LogFormatConfig `json:"log_formats" desc:"Formats de log personnalises, essayes avant les formats integres"`
+	TopValues  int               `json:"top_values" default:"10" min:"1" max:"1000" desc:"Lignes affichees par classement : valeurs par champ de log (menu K), lignes repetees (menu N), n-grammes (menu O)"`
+
+	IncidentBucket    string  `json:"incident_bucket" default:"minute" enum:"minute,hour" desc:"Taille des tranches de temps de la detection d'incidents (menu L)"`
+	IncidentThreshold float64 `json:"incident_threshold" default:"3" min:"1" max:"100" desc:"Ecart au taux d'erreurs de reference (ecarts-types robustes) a partir duquel une tranche est un pic"`
+	IncidentMinErrors int     `json:"incident_min_errors" default:"5" min:"1" desc:"Nombre minimum d'erreurs dans une tranche pour la signaler"`
+	IncidentSamples   int     `json:"incident_samples" default:"3" min:"0" max:"50" desc:"Lignes d'exemple citees par incident"`
+
+	FollowPollMs int `json:"follow_poll_ms" default:"500" min:"50" max:"10000" desc:"Intervalle de verification des fichiers suivis par tail -f (millisecondes)"`
+}
+
+type WebOpsConfig struct {
+	TimeoutSec int    `json:"timeout_sec" default:"10" min:"1" max:"300" desc:"Timeout des requetes HTTP (secondes)"`
+	UserAgent  string `json:"user_agent" default:"GoTools/1.0 (+M1 DevOps project)" required:"true" desc:"User-Agent envoye a Wikipedia"`
+}
+
+type ProcOpsConfig struct {
+	KillSignal string `json:"kill_signal" default:"TERM" enum:"TERM,INT,HUP,KILL" desc:"Signal envoye par kill (Unix uniquement)"`
+}
+
+type SecureOpsConfig struct {
+	ReadOnlyMode  FileMode `json:"read_only_mode" default:"0444" desc:"Permissions appliquees par le passage en lecture seule"`
+	ReadWriteMode FileMode `json:"read_write_mode" default:"0644" desc:"Permissions appliquees par le retour en lecture/ecriture"`
+}
+
+type InfraOpsConfig struct {
+	DiskAlertPercent float64 `json:"disk_alert_percent" default:"10" min:"0" max:"100" desc:"Alerte si l'espace libre passe sous ce pourcentage"`
+	DockerBin        string  `json:"docker_bin" default:"docker" required:"true" desc:"Binaire Docker utilise par le menu F"`
+}
+
+type AuditConfig struct {
+	File    string `json:"file" default:"audit.log" required:"true" desc:"Nom du journal d'audit dans out_dir"`
+	HMACKey string `json:"hmac_key" secret:"true" desc:"Cle HMAC ajoutee au chainage des entrees (vide = hash seul), de preference une reference env: ou file:"`
+
+	Durability string `json:"durability" default:"always" enum:"none,exit,always" desc:"Synchronisation disque (fsync) du journal : a chaque entree, a la sortie du programme, ou laissee au systeme"`
+
+	MaxSizeMB   int    `json:"max_size_mb" default:"10" min:"0" desc:"Rotation quand le journal atteint cette taille (0 = jamais)"`
+	RotateEvery string `json:"rotate_every" default:"daily" enum:"none,hourly,daily" desc:"Rotation periodique du journal"`
+	Compress    bool   `json:"compress" default:"true" desc:"Compresse les journaux tournes en gzip"`
+	MaxFiles    int    `json:"max_files" default:"0" min:"0" desc:"Nombre de journaux tournes conserves (0 = tous)"`
+	MaxAgeDays  int    `json:"max_age_days" default:"0" min:"0" desc:"Supprime les journaux tournes plus vieux que N jours (0 = jamais)"`
+
+	QueryFormat string `json:"query_format" default:"table" enum:"table,json,csv" desc:"Format de sortie par defaut de audit query"`
+
+	Sinks []SinkConfig `json:"sinks" desc:"Destinations supplementaires des evenements, en plus du journal local"`
+}
+
+// LogFormatConfig decrit un format de log par expression reguliere (config.json uniquement)
+type LogFormatConfig struct {
+	Name       string `json:"name" required:"true" desc:"Nom du format (fileops.log_format ou --format)"`
+	Pattern    string `json:"pattern" required:"true" desc:"Expression reguliere a groupes nommes : time, level, status, msg ; les autres groupes sont des champs"`
+	TimeLayout string `json:"time_layout" desc:"Format Go de l'horodatage (ex: 2006-01-02 15:04:05), vide = formats courants"`
+}
+
+// SinkConfig decrit une destination du journal d'audit (config.json uniquement)
+type SinkConfig struct {
+	Type       string `json:"type" enum:"file,syslog,webhook" required:"true" desc:"file (copie locale), syslog (RFC 5424) ou webhook (HTTP POST JSON)"`
+	Path       string `json:"path" desc:"file : fichier de copie (relatif a out_dir)"`
+	Network    string `json:"network" default:"udp" enum:"udp,tcp,unix" desc:"syslog : transport"`
+	Address    string `json:"address" desc:"syslog : hote:port ou chemin du socket unix (ex: /dev/log)"`
+	URL        string `json:"url" secret:"true" desc:"webhook : URL appelee en POST"`
+	Token      string `json:"token" secret:"true" desc:"webhook : jeton envoye en Authorization: Bearer"`
+	Retries    int    `json:"retries" default:"3" min:"1" max:"10" desc:"webhook : tentatives avant mise en tampon"`
+	TimeoutSec int    `json:"timeout_sec" default:"5" min:"1" max:"60" desc:"Timeout reseau (secondes)"`
+	BufferFile string `json:"buffer_file" default:"audit-webhook.buffer" desc:"webhook : evenements non livres, renvoyes a l'envoi suivant (relatif a out_dir) ; les refus 4xx vont dans <buffer_file>.rejected"`
 }
 
 func DefaultConfig() *Config {
-	return &Config{
-		DefaultFile: "data/input.txt",
-		BaseDir:     "data",
-		OutDir:      "out",
-		DefaultExt:  ".txt",
-		WikiLang:    "fr",
-		ProcessTopN: 10,
+	cfg := &Config{}
+	if err := applyDefaults(cfg); err != nil {
+		// les tags default sont fixes dans le code, une erreur ici est un bug
+		panic(err)
 	}
+	return cfg
 }
 
-// Load detecte le format (json ou txt) et charge la config
+// Load detecte le format (json ou txt), resout les references secretes
+// (env:, file:, cmd:) puis valide la config
 func Load(path string) (*Config, error) {
+	var cfg *Config
+	var err error
 	if strings.HasSuffix(path, ".json") {
-		return loadJSON(path)
+		cfg, err = loadJSON(path)
+	} else {
+		cfg, err = loadTXT(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := applyItemDefaults(cfg); err != nil {
+		return nil, err
 	}
-	return loadTXT(path)
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("secret invalide dans %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config invalide dans %s: %w", path, err)
+	}
+	return cfg, nil
 }
 
 func loadJSON(path string) (*Config, error) {
@@ -50,6 +162,7 @@ func loadJSON(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// loadTXT lit des lignes cle=valeur, les sections s'ecrivent "section.cle=valeur"
 func loadTXT(path string) (*Config, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -59,7 +172,9 @@ func loadTXT(path string) (*Config, error) {
 
 	cfg := DefaultConfig()
 	scanner := bufio.NewScanner(f)
+	lineNo := 0
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
@@ -69,24 +184,70 @@ func loadTXT(path string) (*Config, error) {
 			continue
 		}
 		key, val := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
-		switch key {
-		case "default_file":
-			cfg.DefaultFile = val
-		case "base_dir":
-			cfg.BaseDir = val
-		case "out_dir":
-			cfg.OutDir = val
-		case "default_ext":
-			cfg.DefaultExt = val
-		case "wiki_lang":
-			cfg.WikiLang = val
-		case "process_top_n":
-			if n, err := strconv.Atoi(val); err == nil && n >= 0 {
-				cfg.ProcessTopN = n
-			}
+		if err := cfg.Set(key, val); err != nil && err != errUnknownKey {
+			return nil, fmt.Errorf("%s ligne %d: %w", path, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erreur lecture %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate verifie que les valeurs sont utilisables avant de remplacer une config
+func (c *Config) Validate() error {
+	if err := validate(c); err != nil {
+		return err
+	}
+	for i, s := range c.Audit.Sinks {
+		var missing string
+		switch {
+		case s.Type == "file" && s.Path == "":
+			missing = "path"
+		case s.Type == "syslog" && s.Address == "":
+			missing = "address"
+		case s.Type == "webhook" && s.URL == "":
+			missing = "url"
+		}
+		if missing != "" {
+			return fmt.Errorf("audit.sinks.%d.%s obligatoire pour le type %s", i, missing, s.Type)
+		}
+	}
+	for i, f := range c.FileOps.LogFormats {
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("fileops.log_formats.%d.pattern invalide: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Diff liste les cles modifiees entre deux configs, au format "cle: ancien -> nouveau".
+// Les valeurs secretes sont masquees.
+func Diff(old, cur *Config) []string {
+	oldVals, oldShown := map[string]string{}, map[string]string{}
+	for _, f := range fields(old) {
+		oldVals[f.Key] = formatValue(f.Value)
+	}
+	for _, f := range fields(old.Redacted()) {
+		oldShown[f.Key] = formatValue(f.Value)
+	}
+	curShown := map[string]string{}
+	for _, f := range fields(cur.Redacted()) {
+		curShown[f.Key] = formatValue(f.Value)
+	}
+
+	var changes []string
+	for _, f := range fields(cur) {
+		if formatValue(f.Value) == oldVals[f.Key] {
+			continue
+		}
+		before, after := oldShown[f.Key], curShown[f.Key]
+		if old.IsSecret(f.Key) || cur.IsSecret(f.Key) {
+			before, after = Mask, Mask
 		}
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", f.Key, before, after))
 	}
-	return cfg, scanner.Err()
+	return changes
 }
 
 func (c *Config) EnsureOutDir() error {
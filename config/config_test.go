@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -44,3 +45,81 @@ func TestLoadTXT(t *testing.T) {
 		t.Fatalf("process_top_n = %d", cfg.ProcessTopN)
 	}
 }
+
+func TestLoadTXTSections(t *testing.T) {
+	tmp := t.TempDir()
+	p := filepath.Join(tmp, "config.txt")
+	body := "fileops.extensions=.txt, .log\ninfraops.disk_alert_percent=15\nsecureops.read_only_mode=0400\n"
+	if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+		t.Fatalf("write txt: %v", err)
+	}
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load txt: %v", err)
+	}
+	if len(cfg.FileOps.Extensions) != 2 || cfg.FileOps.Extensions[1] != ".log" {
+		t.Fatalf("extensions = %#v", cfg.FileOps.Extensions)
+	}
+	if cfg.InfraOps.DiskAlertPercent != 15 {
+		t.Fatalf("disk_alert_percent = %v", cfg.InfraOps.DiskAlertPercent)
+	}
+	if cfg.SecureOps.ReadOnlyMode.Perm() != 0400 {
+		t.Fatalf("read_only_mode = %v", cfg.SecureOps.ReadOnlyMode)
+	}
+	if cfg.WebOps.TimeoutSec != 10 {
+		t.Fatalf("expected default webops.timeout_sec, got %d", cfg.WebOps.TimeoutSec)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	cases := map[string]string{
+		"infraops.disk_alert_percent": "150",
+		"wiki_lang":                   "xx",
+		"procops.kill_signal":         "STOP",
+		"infraops.docker_bin":         "",
+	}
+	for key, val := range cases {
+		cfg := DefaultConfig()
+		if err := cfg.Set(key, val); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected validation error for %s=%q", key, val)
+		}
+	}
+
+	if err := DefaultConfig().Set("secureops.read_write_mode", "999"); err == nil {
+		t.Fatal("expected invalid octal mode error")
+	}
+}
+
+func TestLoadAuditSinks(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("GOTOOLS_TEST_HOOK_TOKEN", "tok")
+	p := filepath.Join(tmp, "config.json")
+	body := `{"audit":{"sinks":[
+		{"type":"syslog","address":"127.0.0.1:514"},
+		{"type":"webhook","url":"https://siem.example/hook","token":"env:GOTOOLS_TEST_HOOK_TOKEN","retries":5}
+	]}}`
+	if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	sinks := cfg.Audit.Sinks
+	if len(sinks) != 2 || sinks[0].Network != "udp" || sinks[0].TimeoutSec != 5 {
+		t.Fatalf("sink defaults not applied: %+v", sinks)
+	}
+	if sinks[1].Token != "tok" || sinks[1].Retries != 5 || !cfg.IsSecret("audit.sinks.1.token") {
+		t.Fatalf("webhook sink not resolved: %+v", sinks[1])
+	}
+
+	cfg.Audit.Sinks = []SinkConfig{{Type: "webhook", Retries: 3, TimeoutSec: 5, Network: "udp"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "url") {
+		t.Fatalf("expected missing url error, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// WriteDoc genere la documentation des cles (markdown) a partir des tags de Config,
+// elle ne peut donc pas diverger du code.
+func WriteDoc(w io.Writer) error {
+	def := DefaultConfig()
+	fmt.Fprintln(w, "# Configuration GoTools")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Cles acceptees dans config.json (sections imbriquees) et config.txt (`section.cle=valeur`).")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Toute valeur texte peut etre une reference secrete resolue au chargement :")
+	fmt.Fprintln(w, "`env:VAR`, `file:/run/secrets/x` ou `cmd:pass show x`. Elle est alors masquee dans `config show` et les logs.")
+
+	section := "-"
+	t := reflect.TypeOf(*def)
+	for _, f := range fields(def) {
+		name := ""
+		if i := strings.Index(f.Key, "."); i >= 0 {
+			name = f.Key[:i]
+		}
+		if name != section {
+			section = name
+			fmt.Fprintln(w)
+			if name == "" {
+				fmt.Fprintln(w, "## General")
+			} else {
+				sf, _ := fieldByJSONName(t, name)
+				fmt.Fprintf(w, "## %s\n\n%s\n", name, sf.Tag.Get("desc"))
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "| Cle | Type | Defaut | Description |")
+			fmt.Fprintln(w, "|-----|------|--------|-------------|")
+		}
+		fmt.Fprintf(w, "| `%s` | %s | `%s` | %s |\n", f.Key, typeName(f.Value), formatValue(f.Value), describe(f))
+		if isList(f.Value.Type()) {
+			var sub []Field
+			walk(newItem(f.Value.Type().Elem()), f.Key+"[].", &sub)
+			for _, s := range sub {
+				fmt.Fprintf(w, "| `%s` | %s | `%s` | %s |\n", s.Key, typeName(s.Value), formatValue(s.Value), describe(s))
+			}
+		}
+	}
+	return nil
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func typeName(v reflect.Value) string {
+	if _, ok := v.Interface().(FileMode); ok {
+		return "mode octal"
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64:
+		return "entier"
+	case reflect.Float64:
+		return "nombre"
+	case reflect.Bool:
+		return "booleen"
+	case reflect.Slice:
+		if isList(v.Type()) {
+			return "liste d'objets"
+		}
+		return "liste"
+	default:
+		return "texte"
+	}
+}
+
+func describe(f Field) string {
+	d := f.Tag.Get("desc")
+	var rules []string
+	if s, ok := f.Tag.Lookup("min"); ok {
+		rules = append(rules, "min "+s)
+	}
+	if s, ok := f.Tag.Lookup("max"); ok {
+		rules = append(rules, "max "+s)
+	}
+	if s, ok := f.Tag.Lookup("enum"); ok {
+		rules = append(rules, "valeurs: "+strings.ReplaceAll(s, ",", ", "))
+	}
+	if f.Tag.Get("required") == "true" {
+		rules = append(rules, "obligatoire")
+	}
+	if f.Tag.Get("secret") == "true" {
+		rules = append(rules, "secret, toujours masque")
+	}
+	if len(rules) > 0 {
+		d += " (" + strings.Join(rules, "; ") + ")"
+	}
+	return d
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var errUnknownKey = errors.New("cle inconnue")
+
+// FileMode est un mode de permissions ecrit en octal dans la config ("0644")
+type FileMode os.FileMode
+
+func (m FileMode) Perm() os.FileMode { return os.FileMode(m).Perm() }
+
+func (m FileMode) String() string { return fmt.Sprintf("%04o", uint32(m)) }
+
+func (m FileMode) MarshalText() ([]byte, error) { return []byte(m.String()), nil }
+
+func (m *FileMode) UnmarshalText(b []byte) error {
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 8, 32)
+	if err != nil || n > 0777 {
+		return fmt.Errorf("mode octal invalide %q (ex: 0644)", string(b))
+	}
+	*m = FileMode(n)
+	return nil
+}
+
+// Field est une cle "feuille" de la config, avec son chemin complet (ex: "webops.timeout_sec")
+type Field struct {
+	Key   string
+	Tag   reflect.StructTag
+	Value reflect.Value
+}
+
+// fields parcourt la config (sections comprises) dans l'ordre de declaration
+func fields(c *Config) []Field {
+	var out []Field
+	walk(reflect.ValueOf(c).Elem(), "", &out)
+	return out
+}
+
+func walk(v reflect.Value, prefix string, out *[]Field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		fv := v.Field(i)
+		if isSection(sf.Type) {
+			walk(fv, key+".", out)
+			continue
+		}
+		*out = append(*out, Field{Key: key, Tag: sf.Tag, Value: fv})
+	}
+}
+
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// isList indique une liste d'objets (ex: audit.sinks), modifiable seulement en JSON
+func isList(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && isSection(t.Elem())
+}
+
+// items renvoie les cles de chaque element d'une liste d'objets ("audit.sinks.0.type"...)
+func items(f Field) []Field {
+	if !isList(f.Value.Type()) {
+		return nil
+	}
+	var out []Field
+	for i := 0; i < f.Value.Len(); i++ {
+		walk(f.Value.Index(i), fmt.Sprintf("%s.%d.", f.Key, i), &out)
+	}
+	return out
+}
+
+// allFields renvoie les cles de la config plus celles des elements de listes
+func allFields(c *Config) []Field {
+	var out []Field
+	for _, f := range fields(c) {
+		out = append(out, f)
+		out = append(out, items(f)...)
+	}
+	return out
+}
+
+// applyItemDefaults complete les elements de listes charges depuis le JSON :
+// un champ laisse a zero prend la valeur de son tag default
+func applyItemDefaults(c *Config) error {
+	for _, f := range fields(c) {
+		for _, item := range items(f) {
+			def, ok := item.Tag.Lookup("default")
+			if !ok || !item.Value.IsZero() {
+				continue
+			}
+			if err := setValue(item.Value, def); err != nil {
+				return fmt.Errorf("default de %s: %w", item.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newItem renvoie un element de liste vide avec ses valeurs par defaut (doc, schema)
+func newItem(t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	var out []Field
+	walk(v, "", &out)
+	for _, f := range out {
+		if def, ok := f.Tag.Lookup("default"); ok {
+			_ = setValue(f.Value, def)
+		}
+	}
+	return v
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Set modifie une cle a partir de sa representation texte (format config.txt)
+func (c *Config) Set(key, val string) error {
+	for _, f := range fields(c) {
+		if f.Key == key {
+			if err := setValue(f.Value, val); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return errUnknownKey
+}
+
+func applyDefaults(c *Config) error {
+	for _, f := range fields(c) {
+		def, ok := f.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setValue(f.Value, def); err != nil {
+			return fmt.Errorf("default de %s: %w", f.Key, err)
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("entier attendu, recu %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("nombre attendu, recu %q", s)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("booleen attendu (true/false), recu %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("type non modifiable en texte (utiliser config.json)")
+		}
+		var items []string
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				items = append(items, p)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("type %s non supporte", v.Type())
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, _ := m.MarshalText()
+		return string(b)
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	if isList(v.Type()) {
+		if v.Len() == 0 {
+			return "[]"
+		}
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// validate applique les tags required, min, max et enum a toutes les cles
+func validate(c *Config) error {
+	for _, f := range allFields(c) {
+		if err := validateField(f); err != nil {
+			return fmt.Errorf("%s %w", f.Key, err)
+		}
+	}
+	return nil
+}
+
+func validateField(f Field) error {
+	v := f.Value
+	if f.Tag.Get("required") == "true" && v.IsZero() {
+		return fmt.Errorf("ne peut pas etre vide")
+	}
+
+	var num float64
+	isNum := true
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64:
+		num = float64(v.Int())
+	case reflect.Float64:
+		num = v.Float()
+	default:
+		isNum = false
+	}
+	if isNum {
+		if s, ok := f.Tag.Lookup("min"); ok {
+			if min, _ := strconv.ParseFloat(s, 64); num < min {
+				return fmt.Errorf("doit etre >= %s (recu %v)", s, v.Interface())
+			}
+		}
+		if s, ok := f.Tag.Lookup("max"); ok {
+			if max, _ := strconv.ParseFloat(s, 64); num > max {
+				return fmt.Errorf("doit etre <= %s (recu %v)", s, v.Interface())
+			}
+		}
+	}
+
+	if enum, ok := f.Tag.Lookup("enum"); ok {
+		allowed := strings.Split(enum, ",")
+		var values []string
+		switch v.Kind() {
+		case reflect.String:
+			values = []string{v.String()}
+		case reflect.Slice:
+			values = v.Interface().([]string)
+		}
+		for _, val := range values {
+			if !contains(allowed, val) {
+				return fmt.Errorf("doit valoir %s (recu %q)", strings.Join(allowed, ", "), val)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SchemaFile est le schema publie a la racine du projet (reference par "$schema" dans config.json)
+const SchemaFile = "config.schema.json"
+
+// Schema construit le JSON Schema de config.json a partir de Config : types,
+// valeurs par defaut (DefaultConfig), enums, bornes et descriptions viennent
+// des memes tags que la validation, le schema suit donc toujours le code.
+func Schema() map[string]any {
+	def := DefaultConfig()
+	root := objectSchema(reflect.ValueOf(def).Elem())
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "Configuration GoTools"
+	// permet de referencer le schema depuis config.json
+	root["properties"].(map[string]any)["$schema"] = map[string]any{"type": "string"}
+	return root
+}
+
+// WriteSchema ecrit le schema indente sur w
+func WriteSchema(w io.Writer) error {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func objectSchema(v reflect.Value) map[string]any {
+	props := map[string]any{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		var s map[string]any
+		if isSection(sf.Type) {
+			s = objectSchema(v.Field(i))
+		} else if isList(sf.Type) {
+			item := objectSchema(newItem(sf.Type.Elem()))
+			if req := requiredKeys(sf.Type.Elem()); len(req) > 0 {
+				item["required"] = req
+			}
+			s = map[string]any{"type": "array", "items": item, "default": []any{}}
+		} else {
+			s = valueSchema(Field{Key: name, Tag: sf.Tag, Value: v.Field(i)})
+		}
+		if d := sf.Tag.Get("desc"); d != "" {
+			s["description"] = d
+		}
+		props[name] = s
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+// requiredKeys liste les champs required d'un element de liste
+func requiredKeys(t reflect.Type) []string {
+	var req []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("required") == "true" {
+			req = append(req, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+		}
+	}
+	return req
+}
+
+func valueSchema(f Field) map[string]any {
+	v := f.Value
+	s := map[string]any{}
+	switch {
+	case v.Type() == reflect.TypeOf(FileMode(0)):
+		s["type"] = "string"
+		s["pattern"] = "^0?[0-7]{3}$"
+	case v.Kind() == reflect.String:
+		s["type"] = "string"
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		s["type"] = "integer"
+	case v.Kind() == reflect.Float64:
+		s["type"] = "number"
+	case v.Kind() == reflect.Bool:
+		s["type"] = "boolean"
+	case v.Kind() == reflect.Slice:
+		s["type"] = "array"
+		s["items"] = map[string]any{"type": "string"}
+	}
+	s["default"] = defaultValue(v)
+
+	if enum, ok := f.Tag.Lookup("enum"); ok {
+		values := strings.Split(enum, ",")
+		if v.Kind() == reflect.Slice {
+			s["items"].(map[string]any)["enum"] = values
+		} else {
+			s["enum"] = values
+		}
+	}
+	if m, ok := f.Tag.Lookup("min"); ok {
+		n, _ := strconv.ParseFloat(m, 64)
+		s["minimum"] = n
+	}
+	if m, ok := f.Tag.Lookup("max"); ok {
+		n, _ := strconv.ParseFloat(m, 64)
+		s["maximum"] = n
+	}
+	if f.Tag.Get("required") == "true" {
+		if v.Kind() == reflect.Slice {
+			s["minItems"] = 1
+		} else if s["type"] == "string" {
+			s["minLength"] = 1
+		}
+	}
+	if f.Tag.Get("secret") == "true" {
+		s["writeOnly"] = true
+	}
+	return s
+}
+
+func defaultValue(v reflect.Value) any {
+	if m, ok := v.Interface().(FileMode); ok {
+		return m.String()
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return []string{}
+	}
+	return v.Interface()
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// le schema publie doit correspondre au code : sinon regenerer avec
+// "go run . config schema > config.schema.json"
+func TestSchemaFileUpToDate(t *testing.T) {
+	published, err := os.ReadFile(filepath.Join("..", SchemaFile))
+	if err != nil {
+		t.Fatalf("read %s: %v", SchemaFile, err)
+	}
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	if !bytes.Equal(bytes.ReplaceAll(published, []byte("\r\n"), []byte("\n")), buf.Bytes()) {
+		t.Fatalf("%s is out of date, regenerate it with: go run . config schema > %s", SchemaFile, SchemaFile)
+	}
+}
+
+func TestEveryKeyDocumented(t *testing.T) {
+	for _, f := range fields(DefaultConfig()) {
+		if f.Tag.Get("desc") == "" {
+			t.Fatalf("key %s has no desc tag", f.Key)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Mask est affiche a la place d'une valeur secrete
+const Mask = "******"
+
+// une valeur "env:VAR", "file:/chemin" ou "cmd:commande args" est remplacee
+// au chargement par la variable, le contenu du fichier ou la sortie de la commande
+var secretPrefixes = []string{"env:", "file:", "cmd:"}
+
+const secretCmdTimeout = 10 * time.Second
+
+// resolveSecrets remplace les references par leur valeur et retient les cles concernees
+func (c *Config) resolveSecrets() error {
+	for _, f := range allFields(c) {
+		switch f.Value.Kind() {
+		case reflect.String:
+			val, ok, err := resolveRef(f.Value.String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Key, err)
+			}
+			if ok {
+				f.Value.SetString(val)
+				c.markSecret(f.Key)
+			}
+		case reflect.Slice:
+			items, isStrings := f.Value.Interface().([]string)
+			if !isStrings {
+				continue
+			}
+			for i, item := range items {
+				val, ok, err := resolveRef(item)
+				if err != nil {
+					return fmt.Errorf("%s[%d]: %w", f.Key, i, err)
+				}
+				if ok {
+					items[i] = val
+					c.markSecret(f.Key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) markSecret(key string) {
+	if c.secrets == nil {
+		c.secrets = map[string]bool{}
+	}
+	c.secrets[key] = true
+}
+
+// IsSecret indique si la valeur de key ne doit jamais etre affichee :
+// elle vient d'une reference ou la cle est marquee secret:"true"
+func (c *Config) IsSecret(key string) bool {
+	if c.secrets[key] {
+		return true
+	}
+	for _, f := range allFields(c) {
+		if f.Key == key {
+			return f.Tag.Get("secret") == "true"
+		}
+	}
+	return false
+}
+
+// Redacted renvoie une copie de la config ou les secrets sont masques
+func (c *Config) Redacted() *Config {
+	cp := *c
+	// les listes d'objets sont copiees pour ne pas masquer les elements de c
+	for _, f := range fields(&cp) {
+		if isList(f.Value.Type()) && !f.Value.IsNil() {
+			dup := reflect.MakeSlice(f.Value.Type(), f.Value.Len(), f.Value.Len())
+			reflect.Copy(dup, f.Value)
+			f.Value.Set(dup)
+		}
+	}
+	for _, f := range allFields(&cp) {
+		if !c.IsSecret(f.Key) || f.Value.IsZero() {
+			continue
+		}
+		switch f.Value.Kind() {
+		case reflect.String:
+			f.Value.SetString(Mask)
+		case reflect.Slice:
+			if items, ok := f.Value.Interface().([]string); ok {
+				masked := make([]string, len(items))
+				for i := range masked {
+					masked[i] = Mask
+				}
+				f.Value.Set(reflect.ValueOf(masked))
+			}
+		}
+	}
+	return &cp
+}
+
+// MarshalJSON masque toujours les secrets : une config serialisee ne contient
+// jamais les valeurs resolues (affichage, logs ou ecriture sur disque)
+func (c Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	return json.Marshal((*plain)(c.Redacted()))
+}
+
+func (c Config) String() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "config: " + err.Error()
+	}
+	return string(data)
+}
+
+func resolveRef(s string) (string, bool, error) {
+	for _, p := range secretPrefixes {
+		if !strings.HasPrefix(s, p) {
+			continue
+		}
+		ref := strings.TrimSpace(strings.TrimPrefix(s, p))
+		if ref == "" {
+			return "", false, fmt.Errorf("reference %q vide", s)
+		}
+		val, err := resolve(p, ref)
+		if err != nil {
+			return "", false, err
+		}
+		return val, true, nil
+	}
+	return s, false, nil
+}
+
+func resolve(prefix, ref string) (string, error) {
+	switch prefix {
+	case "env:":
+		val, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("variable d'environnement %s non definie", ref)
+		}
+		return val, nil
+	case "file:":
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", fmt.Errorf("secret illisible: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default: // cmd:
+		parts := strings.Fields(ref)
+		ctx, cancel := context.WithTimeout(context.Background(), secretCmdTimeout)
+		defer cancel()
+		// pas de shell : la commande et ses arguments sont passes tels quels
+		out, err := exec.CommandContext(ctx, parts[0], parts[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("commande secret %q en echec: %w", parts[0], err)
+		}
+		// comme pass, on ne garde que la premiere ligne
+		return strings.TrimRight(strings.SplitN(string(out), "\n", 2)[0], "\r"), nil
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretReferencesResolvedAndRedacted(t *testing.T) {
+	tmp := t.TempDir()
+	secretFile := filepath.Join(tmp, "ua")
+	if err := os.WriteFile(secretFile, []byte("agent-from-file\n"), 0600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	t.Setenv("GOTOOLS_TEST_DOCKER", "/opt/docker")
+
+	p := filepath.Join(tmp, "config.json")
+	body := `{"webops":{"user_agent":"file:` + filepath.ToSlash(secretFile) + `"},"infraops":{"docker_bin":"env:GOTOOLS_TEST_DOCKER"}}`
+	if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.WebOps.UserAgent != "agent-from-file" || cfg.InfraOps.DockerBin != "/opt/docker" {
+		t.Fatalf("secrets not resolved: %q %q", cfg.WebOps.UserAgent, cfg.InfraOps.DockerBin)
+	}
+	if !cfg.IsSecret("webops.user_agent") || cfg.IsSecret("out_dir") {
+		t.Fatal("unexpected secret flags")
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(data), "agent-from-file") || strings.Contains(string(data), "/opt/docker") {
+		t.Fatalf("secret leaked in json: %s", data)
+	}
+
+	changed := DefaultConfig()
+	for _, c := range Diff(changed, cfg) {
+		if strings.Contains(c, "/opt/docker") {
+			t.Fatalf("secret leaked in diff: %s", c)
+		}
+	}
+}
+
+func TestSecretReferenceMissingEnv(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.txt")
+	if err := os.WriteFile(p, []byte("infraops.docker_bin=env:GOTOOLS_TEST_UNSET_VAR\n"), 0644); err != nil {
+		t.Fatalf("write txt: %v", err)
+	}
+	if _, err := Load(p); err == nil {
+		t.Fatal("expected error for undefined variable")
+	}
+}
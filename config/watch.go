@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Watcher garde la config courante et la recharge quand le fichier change
+// (ou sur SIGHUP). Une config invalide est ignoree : on garde l'ancienne.
+type Watcher struct {
+	path    string
+	current atomic.Pointer[Config]
+
+	mu      sync.Mutex // un seul rechargement a la fois
+	modTime time.Time
+	size    int64
+
+	logf func(format string, args ...any)
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher prepare la surveillance de path avec initial comme config courante.
+// Si path est vide (aucun fichier de config), Current renvoie toujours initial.
+func NewWatcher(path string, initial *Config) *Watcher {
+	w := &Watcher{
+		path: path,
+		logf: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+	w.current.Store(initial)
+	w.modTime, w.size = fileStamp(path)
+	return w
+}
+
+// SetLogger remplace la sortie des messages de rechargement (stderr par defaut)
+func (w *Watcher) SetLogger(logf func(format string, args ...any)) {
+	w.logf = logf
+}
+
+// Current renvoie la derniere config valide. Le pointeur renvoye n'est jamais
+// modifie : un rechargement publie une nouvelle Config.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Reload relit le fichier, le valide et remplace la config si tout est bon.
+// Renvoie true si la config a change.
+func (w *Watcher) Reload() (bool, error) {
+	if w.path == "" {
+		return false, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.modTime, w.size = fileStamp(w.path)
+	next, err := Load(w.path)
+	if err != nil {
+		w.logf("Config: rechargement refuse, ancienne config conservee (%v)", err)
+		return false, err
+	}
+
+	old := w.current.Load()
+	changes := Diff(old, next)
+	if len(changes) == 0 {
+		return false, nil
+	}
+	w.current.Store(next)
+	w.logf("Config: %s rechargee", w.path)
+	for _, c := range changes {
+		w.logf("  %s", c)
+	}
+	return true, nil
+}
+
+// Start lance la surveillance en arriere-plan : on verifie la date/taille du
+// fichier toutes les interval et on recharge aussi sur SIGHUP.
+func (w *Watcher) Start(interval time.Duration) {
+	if w.path == "" || w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer close(w.done)
+		defer signal.Stop(hup)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-hup:
+				_, _ = w.Reload()
+			case <-ticker.C:
+				if w.changedOnDisk() {
+					_, _ = w.Reload()
+				}
+			}
+		}
+	}()
+}
+
+// Stop arrete la surveillance et attend la fin de la goroutine
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop = nil
+}
+
+func (w *Watcher) changedOnDisk() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	mod, size := fileStamp(w.path)
+	return !mod.Equal(w.modTime) || size != w.size
+}
+
+func fileStamp(path string) (time.Time, int64) {
+	if path == "" {
+		return time.Time{}, 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
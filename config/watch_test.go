@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatcherReloadKeepsPreviousOnInvalid(t *testing.T) {
+	tmp := t.TempDir()
+	p := filepath.Join(tmp, "config.json")
+	if err := os.WriteFile(p, []byte(`{"wiki_lang":"fr"}`), 0644); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	initial, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	w := NewWatcher(p, initial)
+	w.SetLogger(func(string, ...any) {})
+
+	if err := os.WriteFile(p, []byte(`{"wiki_lang":"en"}`), 0644); err != nil {
+		t.Fatalf("rewrite json: %v", err)
+	}
+	changed, err := w.Reload()
+	if err != nil || !changed {
+		t.Fatalf("reload = %v, %v; want changed", changed, err)
+	}
+	if w.Current().WikiLang != "en" {
+		t.Fatalf("wiki_lang = %q, want en", w.Current().WikiLang)
+	}
+
+	if err := os.WriteFile(p, []byte(`{"process_top_n":-3}`), 0644); err != nil {
+		t.Fatalf("rewrite json: %v", err)
+	}
+	if _, err := w.Reload(); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if w.Current().WikiLang != "en" || w.Current().ProcessTopN != 10 {
+		t.Fatalf("previous config not kept: %+v", w.Current())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := DefaultConfig()
+	b := DefaultConfig()
+	b.OutDir = "build"
+	changes := Diff(a, b)
+	if len(changes) != 1 || changes[0] != "out_dir: out -> build" {
+		t.Fatalf("unexpected diff: %#v", changes)
+	}
+}
@@ -0,0 +1,216 @@
+package fileops
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// formats compresses reconnus a leurs premiers octets ; l'extension ne sert
+// qu'a lister les fichiers (app.log.gz compte comme .log)
+var compressions = []struct {
+	name  string
+	ext   string
+	magic []byte
+	check func(head []byte) bool // controle du reste de l'en-tete, si besoin
+}{
+	{"gzip", ".gz", []byte{0x1F, 0x8B}, nil},
+	{"bzip2", ".bz2", []byte("BZh"), isBzip2},
+	{"zstd", ".zst", []byte{0x28, 0xB5, 0x2F, 0xFD}, nil},
+}
+
+// headerLen est le nombre d'octets a lire pour detectCompression
+const headerLen = 10
+
+// detectCompression renvoie le format compresse de l'en-tete, "" sinon
+func detectCompression(head []byte) string {
+	for _, c := range compressions {
+		if bytes.HasPrefix(head, c.magic) && (c.check == nil || c.check(head)) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// isBzip2 : "BZh" suivi de la taille de bloc (1-9) puis de l'en-tete du
+// premier bloc, ou de la fin de flux pour un fichier vide. "BZh" seul peut
+// ouvrir une ligne de texte ordinaire.
+func isBzip2(head []byte) bool {
+	if len(head) < headerLen || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+	block := string(head[4:10])
+	return block == "1AY&SY" || block == "\x17rE8P\x90"
+}
+
+// trimCompressionExt retire une extension de compression : "a.log.gz" -> "a.log"
+func trimCompressionExt(name string) string {
+	lower := strings.ToLower(name)
+	for _, c := range compressions {
+		if strings.HasSuffix(lower, c.ext) {
+			return name[:len(name)-len(c.ext)]
+		}
+	}
+	return name
+}
+
+// openRaw ouvre un fichier et le decompresse si besoin (gzip, bzip2, zstd via la
+// commande zstd) ; le lecteur renvoye compte les octets decompresses
+func openRaw(path string) (*rawReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("impossible d'ouvrir %s: %w", path, err)
+	}
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(headerLen)
+	rr := &rawReader{compression: detectCompression(head), closers: []io.Closer{f}}
+
+	switch rr.compression {
+	case "gzip":
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lecture gzip %s: %w", path, err)
+		}
+		rr.r = gz
+	case "bzip2":
+		rr.r = bzip2.NewReader(br)
+	case "zstd":
+		// pas de zstd dans la bibliotheque standard : on passe par la commande
+		f.Close()
+		zr, err := startZstd(path)
+		if err != nil {
+			return nil, err
+		}
+		rr.r, rr.closers = zr, []io.Closer{zr}
+	default:
+		rr.r = br
+	}
+	return rr, nil
+}
+
+// rawReader lit le contenu decompresse d'un fichier
+type rawReader struct {
+	r           io.Reader
+	closers     []io.Closer
+	compression string
+	n           int64
+}
+
+func (rr *rawReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.n += int64(n)
+	return n, err
+}
+
+func (rr *rawReader) Close() error {
+	var err error
+	for _, c := range rr.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	rr.closers = nil
+	return err
+}
+
+// zstdReader lit la sortie de "zstd -dc" ; une erreur de la commande est
+// renvoyee a la fin du flux
+type zstdReader struct {
+	cmd    *exec.Cmd
+	out    io.ReadCloser
+	stderr bytes.Buffer
+	path   string
+	done   bool
+	err    error // fin du flux : io.EOF ou l'erreur de zstd
+}
+
+func startZstd(path string) (*zstdReader, error) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		return nil, fmt.Errorf("%s est compresse en zstd mais la commande zstd est introuvable dans le PATH : installez le paquet zstd (ex: apt install zstd, brew install zstd)", path)
+	}
+	z := &zstdReader{path: path}
+	z.cmd = exec.Command("zstd", "-dcq", "--", path)
+	z.cmd.Stderr = &z.stderr
+	var err error
+	if z.out, err = z.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err := z.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("lancement de zstd: %w", err)
+	}
+	return z, nil
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if z.done {
+		return 0, z.err
+	}
+	n, err := z.out.Read(p)
+	if err == io.EOF {
+		z.done, z.err = true, io.EOF
+		if werr := z.cmd.Wait(); werr != nil {
+			z.err = fmt.Errorf("zstd %s: %s", z.path, strings.TrimSpace(z.stderr.String()))
+		}
+		return n, z.err
+	}
+	return n, err
+}
+
+// Close arrete zstd si la lecture s'est arretee avant la fin
+func (z *zstdReader) Close() error {
+	if z.done {
+		return nil
+	}
+	z.done, z.err = true, io.EOF
+	z.cmd.Process.Kill()
+	z.cmd.Wait()
+	return nil
+}
+
+// uncompressedSize decompresse le fichier pour mesurer son contenu
+func uncompressedSize(path string) (int64, string, error) {
+	rr, err := openRaw(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rr.Close()
+	if _, err := io.Copy(io.Discard, rr); err != nil {
+		return 0, "", fmt.Errorf("lecture %s: %w", path, err)
+	}
+	return rr.n, rr.compression, nil
+}
+
+// contentSize renvoie la taille du contenu : celle du fichier s'il n'est pas
+// compresse, sinon il est decompresse pour la mesurer
+func contentSize(path string, info os.FileInfo) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("impossible d'ouvrir %s: %w", path, err)
+	}
+	head := make([]byte, headerLen)
+	n, _ := io.ReadFull(f, head)
+	f.Close()
+	if detectCompression(head[:n]) == "" {
+		return info.Size(), "", nil
+	}
+	return uncompressedSize(path)
+}
+
+// sizeLabel decrit la taille d'un fichier, et celle de son contenu s'il est compresse
+func sizeLabel(size int64, tf TextFormat) string {
+	if tf.Compression == "" {
+		return fmt.Sprintf("%d octets", size)
+	}
+	ratio := 0.0
+	if size > 0 {
+		ratio = float64(tf.Size) / float64(size)
+	}
+	return fmt.Sprintf("%d octets (%s, %d octets decompresse, ratio %.1f)", size, tf.Compression, tf.Size, ratio)
+}
@@ -0,0 +1,136 @@
+package fileops
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// "alpha beta\ngamma\n" compresse par bzip2 -9
+var bzip2Sample = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x8b, 0x5b,
+	0x79, 0x86, 0x00, 0x00, 0x03, 0x51, 0x80, 0x00, 0x10, 0x40, 0x00, 0x32,
+	0xc6, 0x44, 0x00, 0x20, 0x00, 0x31, 0x00, 0xd3, 0x4d, 0x05, 0x30, 0x1e,
+	0xa6, 0x8f, 0xf1, 0x12, 0x5e, 0x42, 0x06, 0x4b, 0x85, 0xdc, 0x91, 0x4e,
+	0x14, 0x24, 0x22, 0xd6, 0xde, 0x61, 0x80,
+}
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAnalyzeCompressedInputs(t *testing.T) {
+	tmp := t.TempDir()
+	plain := "alpha beta\ngamma\n"
+	files := map[string][]byte{
+		"a.txt.gz":  gzipBytes(t, plain),
+		"b.txt.bz2": bzip2Sample,
+		// l'en-tete prime sur l'extension
+		"c.txt": gzipBytes(t, plain),
+	}
+	want := map[string]string{"a.txt.gz": "gzip", "b.txt.bz2": "bzip2", "c.txt": "gzip"}
+	if _, err := exec.LookPath("zstd"); err == nil {
+		src := filepath.Join(tmp, "d.src")
+		os.WriteFile(src, []byte(plain), 0644)
+		if out, err := exec.Command("zstd", "-q", src, "-o", filepath.Join(tmp, "d.txt.zst")).CombinedOutput(); err != nil {
+			t.Fatalf("zstd: %v %s", err, out)
+		}
+		want["d.txt.zst"] = "zstd"
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), data, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	for name, compression := range want {
+		p := filepath.Join(tmp, name)
+		st, err := Analyze(p, AnalyzeOptions{N: 1})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if st.Format.Compression != compression || st.Format.Size != int64(len(plain)) {
+			t.Fatalf("%s: format = %+v", name, st.Format)
+		}
+		if st.Lines != 2 || st.Text.Words != 3 || !reflect.DeepEqual(st.Tail, []string{"gamma"}) {
+			t.Fatalf("%s: lines=%d words=%d tail=%q", name, st.Lines, st.Text.Words, st.Tail)
+		}
+		tail, err := tailLines(p, 1)
+		if err != nil || !reflect.DeepEqual(tail, []string{"gamma"}) {
+			t.Fatalf("%s: tail = %q, %v", name, tail, err)
+		}
+	}
+
+	got, err := FindTxtFiles(tmp)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("FindTxtFiles = %q, want %d files", got, len(want))
+	}
+
+	if err := GenerateIndex(tmp, tmp); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	index, _ := os.ReadFile(filepath.Join(tmp, "index.txt"))
+	for _, l := range strings.Split(string(index), "\n") {
+		if strings.Contains(l, "a.txt.gz") && !strings.Contains(l, " 17 gzip") {
+			t.Fatalf("index line for a.txt.gz lacks content size: %q", l)
+		}
+	}
+}
+
+func TestPlainTextStartingWithBZh(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(p, []byte("BZh9 handshake ok\nnext line\n"), 0644)
+	st, err := Analyze(p, AnalyzeOptions{})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if st.Format.Compression != "" || st.Lines != 2 {
+		t.Fatalf("plain text taken for bzip2: %+v, %d lines", st.Format, st.Lines)
+	}
+	if detectCompression(bzip2Sample[:headerLen]) != "bzip2" {
+		t.Fatal("bzip2 header not detected")
+	}
+}
+
+func TestCompressedOutput(t *testing.T) {
+	old := settings
+	t.Cleanup(func() { settings = old })
+	settings.CompressOutput = "gzip"
+
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "in.txt")
+	os.WriteFile(in, []byte("one\ntwo\n"), 0644)
+	if err := Head(in, 1, tmp); err != nil {
+		t.Fatalf("head: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(tmp, "head.txt.gz"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if string(data) != "one\n" {
+		t.Fatalf("head.txt.gz = %q", data)
+	}
+}
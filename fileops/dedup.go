@@ -0,0 +1,200 @@
+package fileops
+
+import (
+	"encoding/csv"
+	"fmt"
+	"hash/maphash"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DedupOptions regle la recherche des lignes en double
+type DedupOptions struct {
+	Normalize  bool // nombres, UUID, dates, IP et hexadecimal remplaces avant comparaison
+	IgnoreCase bool
+}
+
+// DupGroup est une ligne repetee (ou une famille de lignes si normalisee)
+type DupGroup struct {
+	Count int
+	First int    // numero de la premiere occurrence
+	Line  string // premiere occurrence telle quelle
+	Key   string // forme comparee (normalisee)
+}
+
+// DupResult resume les doublons d'un fichier
+type DupResult struct {
+	Lines  int
+	Unique int
+	Groups []DupGroup // lignes presentes plus d'une fois, les plus frequentes d'abord
+
+	Normalized bool
+
+	DedupFile string // copie sans doublons et liste des doublons dans outDir
+	DupsFile  string
+}
+
+// parties variables d'une ligne de log, remplacees dans cet ordre
+var normalizers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`), "<TS>"},
+	{regexp.MustCompile(`\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2}(?: [+-]\d{4})?`), "<TS>"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`), "<DATE>"},
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}(?:[.,]\d+)?\b`), "<TIME>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<UUID>"},
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<IP>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<HEX>"},
+}
+
+var (
+	hexWord = regexp.MustCompile(`(?i)\b[0-9a-f]{8,}\b`)
+	number  = regexp.MustCompile(`\d+(?:\.\d+)?`)
+)
+
+// normalizeLine remplace les parties variables : "user 42 at 10:00:01" -> "user <N> at <TIME>"
+func normalizeLine(s string) string {
+	for _, n := range normalizers {
+		s = n.re.ReplaceAllString(s, n.repl)
+	}
+	// empreintes et identifiants hexadecimaux, mais pas les mots ("deadbeef" ou "facade" restent)
+	s = hexWord.ReplaceAllStringFunc(s, func(w string) string {
+		if strings.ContainsAny(w, "0123456789") {
+			return "<HEX>"
+		}
+		return w
+	})
+	return number.ReplaceAllString(s, "<N>")
+}
+
+// FindDuplicates compte les lignes identiques (uniq -c apres tri). Le premier
+// passage ne garde qu'une empreinte par ligne distincte et ecrit la copie sans
+// doublons ; le second relit le fichier pour le texte des lignes repetees.
+// Avec Normalize, les lignes qui ne different que par leurs nombres, dates,
+// UUID... sont regroupees et une seule est gardee dans la copie.
+func FindDuplicates(path string, o DedupOptions, outDir string) (*DupResult, error) {
+	type group struct {
+		count, first int
+	}
+	seed := maphash.MakeSeed()
+	key := func(line string) string {
+		if o.IgnoreCase {
+			line = strings.ToLower(line)
+		}
+		if o.Normalize {
+			line = normalizeLine(line)
+		}
+		return line
+	}
+
+	var dedup *outputFile
+	if outDir != "" {
+		var err error
+		if dedup, err = createOutput(filepath.Join(outDir, "deduplicated.txt")); err != nil {
+			return nil, err
+		}
+		defer dedup.Close()
+	}
+
+	res := &DupResult{Normalized: o.Normalize}
+	groups := map[uint64]*group{}
+	err := scanLines(path, func(line string) bool {
+		res.Lines++
+		h := maphash.String(seed, key(line))
+		if g := groups[h]; g != nil {
+			g.count++
+			return true
+		}
+		groups[h] = &group{count: 1, first: res.Lines}
+		if dedup != nil {
+			dedup.WriteString(line)
+			dedup.WriteByte('\n')
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Unique = len(groups)
+
+	// second passage : texte des premieres occurrences repetees
+	firsts := map[int]int{} // numero de ligne -> nombre d'occurrences
+	for _, g := range groups {
+		if g.count > 1 {
+			firsts[g.first] = g.count
+		}
+	}
+	if len(firsts) > 0 {
+		n := 0
+		err = scanLines(path, func(line string) bool {
+			n++
+			if c, ok := firsts[n]; ok {
+				res.Groups = append(res.Groups, DupGroup{Count: c, First: n, Line: line, Key: key(line)})
+			}
+			return len(res.Groups) < len(firsts)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(res.Groups, func(i, j int) bool { return res.Groups[i].Count > res.Groups[j].Count })
+
+	if dedup == nil {
+		return res, nil
+	}
+	if err := dedup.Close(); err != nil {
+		return nil, err
+	}
+	res.DedupFile = dedup.Path
+	if res.DupsFile, err = res.writeGroups(filepath.Join(outDir, "duplicates.csv")); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *DupResult) writeGroups(path string) (string, error) {
+	out, err := createOutput(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.Write([]string{"count", "first_line", "line", "pattern"})
+	for _, g := range r.Groups {
+		w.Write([]string{strconv.Itoa(g.Count), strconv.Itoa(g.First), g.Line, g.Key})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return out.Path, out.Close()
+}
+
+// PrintDuplicates affiche le resultat de FindDuplicates, facon uniq -c | sort -rn ;
+// top = lignes affichees
+func PrintDuplicates(r *DupResult, top int) {
+	dups := r.Lines - r.Unique
+	fmt.Printf("  Lignes   : %d, dont %d distinctes\n", r.Lines, r.Unique)
+	fmt.Printf("  Doublons : %d ligne(s) en trop (%.1f%%), %d ligne(s) repetee(s)\n", dups, percent(dups, r.Lines), len(r.Groups))
+	if len(r.Groups) > 0 {
+		shown := r.Groups
+		if top > 0 && len(shown) > top {
+			shown = shown[:top]
+		}
+		fmt.Printf("\n--- Lignes les plus repetees (top %d) ---\n", len(shown))
+		for _, g := range shown {
+			fmt.Printf("  %7d  %s\n", g.Count, truncate(g.Line, 160))
+			if r.Normalized && g.Key != g.Line {
+				fmt.Printf("  %7s  ~ %s\n", "", truncate(g.Key, 160))
+			}
+		}
+	}
+	if r.DedupFile != "" {
+		fmt.Printf("\n  -> copie sans doublons dans %s\n", r.DedupFile)
+		fmt.Printf("  -> doublons dans %s\n", r.DupsFile)
+	}
+}
@@ -0,0 +1,49 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	cases := map[string]string{
+		"2026-10-19T10:00:01.123+02:00 user 42 at 10.0.0.1:8080":  "<TS> user <N> at <IP>",
+		`1.2.3.4 [19/Oct/2026:10:00:00 +0200] "GET /a/17"`:        `<IP> [<TS>] "GET /a/<N>"`,
+		"job 3F2504E0-4F89-11D3-9A0C-0305E82C3301 done in 1.5s":   "job <UUID> done in <N>s",
+		"commit 9fceb02d0ae598e95dc970b74767f19372d61af8 at 0x1F": "commit <HEX> at <HEX>",
+		"deadbeef facade on 2026-10-19, 10:00:00":                 "deadbeef facade on <DATE>, <TIME>",
+	}
+	for in, want := range cases {
+		if got := normalizeLine(in); got != want {
+			t.Fatalf("normalizeLine(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "app.log")
+	os.WriteFile(in, []byte("start\nretry 1\nRetry 2\nstart\nretry 3\nstart\nstop\n"), 0644)
+
+	res, err := FindDuplicates(in, DedupOptions{}, tmp)
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if res.Lines != 7 || res.Unique != 5 || len(res.Groups) != 1 || res.Groups[0] != (DupGroup{3, 1, "start", "start"}) {
+		t.Fatalf("plain: %+v", res)
+	}
+	data, _ := os.ReadFile(res.DedupFile)
+	if string(data) != "start\nretry 1\nRetry 2\nretry 3\nstop\n" {
+		t.Fatalf("dedup copy = %q", data)
+	}
+
+	res, err = FindDuplicates(in, DedupOptions{Normalize: true, IgnoreCase: true}, "")
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	want := []DupGroup{{3, 1, "start", "start"}, {3, 2, "retry 1", "retry <N>"}}
+	if res.Unique != 3 || len(res.Groups) != 2 || res.Groups[0] != want[0] || res.Groups[1] != want[1] {
+		t.Fatalf("normalized: %+v", res)
+	}
+}
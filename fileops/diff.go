@@ -0,0 +1,430 @@
+package fileops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	diffDel  = "\033[31m"
+	diffAdd  = "\033[32m"
+	diffHunk = "\033[36m"
+)
+
+// DiffOptions regle la comparaison de deux fichiers
+type DiffOptions struct {
+	IgnoreSpace bool // espaces en plus ou en moins ignores (debut, fin, repetitions)
+	IgnoreCase  bool
+	Context     int  // lignes de contexte autour des changements
+	SideBySide  bool // deux colonnes au lieu du format unifie
+	Width       int  // largeur totale en cote a cote
+	Color       bool // couleurs a l'ecran (jamais dans le fichier)
+}
+
+// diffOp est une ligne du script d'edition : '=' commune, '-' supprimee de A,
+// '+' ajoutee dans B. ai et bi sont les positions dans A et B.
+type diffOp struct {
+	kind   byte
+	ai, bi int
+}
+
+// DiffResult est la comparaison ligne a ligne de deux fichiers
+type DiffResult struct {
+	A, B           string
+	Added, Removed int
+	Hunks          int
+	File           string // diff.patch ou diff.txt dans outDir
+
+	o          DiffOptions
+	linesA     []string
+	linesB     []string
+	ops        []diffOp
+	hunkRanges [][2]int // bornes des blocs dans ops
+}
+
+// Identical indique qu'aucune ligne ne differe (aux options pres)
+func (r *DiffResult) Identical() bool { return r.Added == 0 && r.Removed == 0 }
+
+// DiffFiles compare a et b ligne a ligne (algorithme de Myers). Le resultat est
+// ecrit au format unifie (diff.patch) ou cote a cote (diff.txt) dans outDir.
+func DiffFiles(a, b string, o DiffOptions, outDir string) (*DiffResult, error) {
+	if o.Context < 0 {
+		o.Context = 0
+	}
+	if o.Width <= 0 {
+		o.Width = 130
+	}
+	r := &DiffResult{A: a, B: b, o: o}
+	var err error
+	if r.linesA, err = readAllLines(a); err != nil {
+		return nil, err
+	}
+	if r.linesB, err = readAllLines(b); err != nil {
+		return nil, err
+	}
+
+	// les lignes deviennent des identifiants : comparaison rapide et options appliquees une fois
+	ids := map[string]int{}
+	key := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, l := range lines {
+			if o.IgnoreCase {
+				l = strings.ToLower(l)
+			}
+			if o.IgnoreSpace {
+				l = strings.Join(strings.Fields(l), " ")
+			}
+			id, ok := ids[l]
+			if !ok {
+				id = len(ids)
+				ids[l] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	r.ops = diffScript(key(r.linesA), key(r.linesB))
+	for _, op := range r.ops {
+		switch op.kind {
+		case '-':
+			r.Removed++
+		case '+':
+			r.Added++
+		}
+	}
+	r.hunkRanges = hunks(r.ops, o.Context)
+	r.Hunks = len(r.hunkRanges)
+
+	if outDir == "" || r.Identical() {
+		return r, nil
+	}
+	name := "diff.patch"
+	if o.SideBySide {
+		name = "diff.txt"
+	}
+	out, err := createOutput(filepath.Join(outDir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	r.write(out, false)
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	r.File = out.Path
+	return r, nil
+}
+
+func readAllLines(path string) ([]string, error) {
+	var lines []string
+	err := scanLines(path, func(line string) bool {
+		lines = append(lines, line)
+		return true
+	})
+	return lines, err
+}
+
+// differ marque les lignes supprimees de A et ajoutees dans B
+type differ struct {
+	a, b     []int
+	del, ins []bool
+}
+
+// diffScript renvoie le script d'edition le plus court de a vers b
+func diffScript(a, b []int) []diffOp {
+	d := &differ{a: a, b: b, del: make([]bool, len(a)), ins: make([]bool, len(b))}
+	d.compare(0, len(a), 0, len(b))
+
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && d.del[i]:
+			ops = append(ops, diffOp{'-', i, j})
+			i++
+		case j < len(b) && d.ins[j]:
+			ops = append(ops, diffOp{'+', i, j})
+			j++
+		default:
+			ops = append(ops, diffOp{'=', i, j})
+			i++
+			j++
+		}
+	}
+	return ops
+}
+
+// compare traite a[a0:a1] et b[b0:b1] : debut et fin communs ecartes, puis
+// decoupage au milieu du chemin le plus court (espace memoire lineaire)
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		a0++
+		b0++
+	}
+	for a0 < a1 && b0 < b1 && d.a[a1-1] == d.b[b1-1] {
+		a1--
+		b1--
+	}
+	switch {
+	case a0 == a1:
+		for j := b0; j < b1; j++ {
+			d.ins[j] = true
+		}
+		return
+	case b0 == b1:
+		for i := a0; i < a1; i++ {
+			d.del[i] = true
+		}
+		return
+	}
+	x, y := d.bisect(a0, a1, b0, b1)
+	if x < 0 || (x == a0 && y == b0) || (x == a1 && y == b1) {
+		// pas de decoupage utile : tout est remplace
+		for i := a0; i < a1; i++ {
+			d.del[i] = true
+		}
+		for j := b0; j < b1; j++ {
+			d.ins[j] = true
+		}
+		return
+	}
+	d.compare(a0, x, b0, y)
+	d.compare(x, a1, y, b1)
+}
+
+// bisect cherche le "middle snake" de Myers en avancant depuis le debut et
+// depuis la fin ; renvoie le point de decoupage dans a et b
+func (d *differ) bisect(a0, a1, b0, b1 int) (int, int) {
+	n, m := a1-a0, b1-b0
+	maxD := (n + m + 1) / 2
+	off := maxD
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[off+1], vb[off+1] = 0, 0
+	delta := n - m
+	front := delta%2 != 0 // parite : qui des deux parcours detecte le croisement
+	kfStart, kfEnd, kbStart, kbEnd := 0, 0, 0, 0
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + kfStart; k <= step-kfEnd; k += 2 {
+			ko := off + k
+			var x int
+			if k == -step || (k != step && vf[ko-1] < vf[ko+1]) {
+				x = vf[ko+1]
+			} else {
+				x = vf[ko-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			vf[ko] = x
+			switch {
+			case x > n:
+				kfEnd += 2
+			case y > m:
+				kfStart += 2
+			case front:
+				if bo := off + delta - k; bo >= 0 && bo < len(vb) && vb[bo] != -1 && x >= n-vb[bo] {
+					return a0 + x, b0 + y
+				}
+			}
+		}
+		for k := -step + kbStart; k <= step-kbEnd; k += 2 {
+			ko := off + k
+			var x int
+			if k == -step || (k != step && vb[ko-1] < vb[ko+1]) {
+				x = vb[ko+1]
+			} else {
+				x = vb[ko-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[a1-x-1] == d.b[b1-y-1] {
+				x++
+				y++
+			}
+			vb[ko] = x
+			switch {
+			case x > n:
+				kbEnd += 2
+			case y > m:
+				kbStart += 2
+			case !front:
+				if fo := off + delta - k; fo >= 0 && fo < len(vf) && vf[fo] != -1 {
+					fx := vf[fo]
+					if fx >= n-x {
+						return a0 + fx, b0 + fx - (fo - off)
+					}
+				}
+			}
+		}
+	}
+	return -1, -1
+}
+
+// hunks regroupe les changements en blocs avec ctx lignes de contexte ; deux
+// changements separes de moins de 2*ctx lignes communes sont dans le meme bloc
+func hunks(ops []diffOp, ctx int) [][2]int {
+	var out [][2]int
+	for i := 0; i < len(ops); {
+		if ops[i].kind == '=' {
+			i++
+			continue
+		}
+		start := max(i-ctx, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != '=' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == '=' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*ctx {
+				end = min(end+ctx, len(ops))
+				break
+			}
+			end = run
+		}
+		out = append(out, [2]int{start, end})
+		i = end
+	}
+	return out
+}
+
+// hunkHeader renvoie "@@ -a,n +b,m @@" ; une longueur nulle donne la ligne
+// precedente, comme diff -u
+func hunkHeader(ops []diffOp) string {
+	na, nb := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			na++
+		}
+		if op.kind != '-' {
+			nb++
+		}
+	}
+	rng := func(start, n int) string {
+		switch n {
+		case 0:
+			return fmt.Sprintf("%d,0", start)
+		case 1:
+			return fmt.Sprint(start + 1)
+		}
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+	return fmt.Sprintf("@@ -%s +%s @@", rng(ops[0].ai, na), rng(ops[0].bi, nb))
+}
+
+func paint(s, color string, on bool) string {
+	if !on {
+		return s
+	}
+	return color + s + hlEnd
+}
+
+// write ecrit le diff (unifie ou cote a cote)
+func (r *DiffResult) write(w io.Writer, color bool) {
+	if r.o.SideBySide {
+		r.writeSideBySide(w, color)
+		return
+	}
+	fmt.Fprintln(w, paint("--- "+r.A, diffDel, color))
+	fmt.Fprintln(w, paint("+++ "+r.B, diffAdd, color))
+	for _, h := range r.hunkRanges {
+		ops := r.ops[h[0]:h[1]]
+		fmt.Fprintln(w, paint(hunkHeader(ops), diffHunk, color))
+		for _, op := range ops {
+			switch op.kind {
+			case '=':
+				fmt.Fprintln(w, " "+r.linesA[op.ai])
+			case '-':
+				fmt.Fprintln(w, paint("-"+r.linesA[op.ai], diffDel, color))
+			case '+':
+				fmt.Fprintln(w, paint("+"+r.linesB[op.bi], diffAdd, color))
+			}
+		}
+	}
+}
+
+// writeSideBySide affiche A a gauche et B a droite : | ligne modifiee,
+// < supprimee, > ajoutee
+func (r *DiffResult) writeSideBySide(w io.Writer, color bool) {
+	col := max((r.o.Width-3)/2, 10)
+	row := func(left, mark, right string) {
+		line := strings.TrimRight(cell(left, col)+" "+mark+" "+cell(right, col), " ")
+		switch mark {
+		case "<":
+			line = paint(line, diffDel, color)
+		case ">":
+			line = paint(line, diffAdd, color)
+		case "|":
+			line = paint(line, diffHunk, color)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%s   %s\n", cell(r.A, col), r.B)
+	for _, h := range r.hunkRanges {
+		ops := r.ops[h[0]:h[1]]
+		fmt.Fprintln(w, paint(hunkHeader(ops), diffHunk, color))
+		for i := 0; i < len(ops); {
+			if ops[i].kind == '=' {
+				row(r.linesA[ops[i].ai], " ", r.linesB[ops[i].bi])
+				i++
+				continue
+			}
+			// bloc de changements : suppressions en face des ajouts
+			var dels, adds []string
+			for ; i < len(ops) && ops[i].kind != '='; i++ {
+				if ops[i].kind == '-' {
+					dels = append(dels, r.linesA[ops[i].ai])
+				} else {
+					adds = append(adds, r.linesB[ops[i].bi])
+				}
+			}
+			for k := 0; k < max(len(dels), len(adds)); k++ {
+				switch {
+				case k < len(dels) && k < len(adds):
+					row(dels[k], "|", adds[k])
+				case k < len(dels):
+					row(dels[k], "<", "")
+				default:
+					row("", ">", adds[k])
+				}
+			}
+		}
+	}
+}
+
+// cell coupe ou complete s a n caracteres (tabulations en 4 espaces)
+func cell(s string, n int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if c := utf8.RuneCountInString(s); c <= n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	rs := []rune(s)
+	return string(rs[:n-1]) + "~"
+}
+
+// PrintDiff affiche le resultat de DiffFiles
+func PrintDiff(r *DiffResult) {
+	if r.Identical() {
+		fmt.Printf("  Fichiers identiques (%d lignes)\n", len(r.linesA))
+		return
+	}
+	r.write(os.Stdout, r.o.Color)
+	fmt.Printf("\n  %d ligne(s) supprimee(s), %d ajoutee(s), %d bloc(s)\n", r.Removed, r.Added, r.Hunks)
+	if r.File != "" {
+		fmt.Printf("  -> diff ecrit dans %s\n", r.File)
+	}
+}
@@ -0,0 +1,138 @@
+package fileops
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lcsLen est la reference quadratique pour verifier que le script est minimal
+func lcsLen(a, b []int) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiffScriptIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	seq := func() []int {
+		s := make([]int, rng.Intn(40))
+		for i := range s {
+			s[i] = rng.Intn(5)
+		}
+		return s
+	}
+	for n := 0; n < 500; n++ {
+		a, b := seq(), seq()
+		ops := diffScript(a, b)
+		var gotA, gotB []int
+		edits := 0
+		for _, op := range ops {
+			switch op.kind {
+			case '=':
+				if a[op.ai] != b[op.bi] {
+					t.Fatalf("equal op on different lines: %v %v", a, b)
+				}
+				gotA, gotB = append(gotA, a[op.ai]), append(gotB, b[op.bi])
+			case '-':
+				gotA = append(gotA, a[op.ai])
+				edits++
+			case '+':
+				gotB = append(gotB, b[op.bi])
+				edits++
+			}
+		}
+		if len(gotA) != len(a) || len(gotB) != len(b) {
+			t.Fatalf("script does not cover inputs: %v %v", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcsLen(a, b); edits != want {
+			t.Fatalf("%d edits, want %d for %v -> %v", edits, want, a, b)
+		}
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	tmp := t.TempDir()
+	a := filepath.Join(tmp, "a.txt")
+	b := filepath.Join(tmp, "b.txt")
+	os.WriteFile(a, []byte("un\ndeux\ntrois\nquatre\ncinq\nsix\nsept\nhuit\n"), 0644)
+	os.WriteFile(b, []byte("un\nDeux\ntrois\nquatre\ncinq\nsix\nsept  \nhuit\nneuf\n"), 0644)
+
+	r, err := DiffFiles(a, b, DiffOptions{Context: 1}, tmp)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	data, err := os.ReadFile(r.File)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	want := "--- " + a + "\n+++ " + b + "\n" +
+		"@@ -1,3 +1,3 @@\n un\n-deux\n+Deux\n trois\n" +
+		"@@ -6,3 +6,4 @@\n six\n-sept\n+sept  \n huit\n+neuf\n"
+	if string(data) != want {
+		t.Fatalf("patch =\n%s\nwant\n%s", data, want)
+	}
+
+	r, err = DiffFiles(a, b, DiffOptions{IgnoreCase: true, IgnoreSpace: true}, "")
+	if err != nil || r.Added != 1 || r.Removed != 0 || r.Hunks != 1 {
+		t.Fatalf("ignore options: %v %+v", err, r)
+	}
+
+	var sb strings.Builder
+	r, _ = DiffFiles(a, b, DiffOptions{IgnoreCase: true, SideBySide: true, Width: 23}, "")
+	r.write(&sb, false)
+	if !strings.Contains(sb.String(), "sept       | sept\n") || !strings.Contains(sb.String(), "           > neuf\n") {
+		t.Fatalf("side by side:\n%s", sb.String())
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "docs")
+	os.Mkdir(dir, 0755)
+	write := func(name, content string) { os.WriteFile(filepath.Join(dir, name), []byte(content), 0644) }
+	write("a.txt", "un deux\n")
+	write("b.txt", "trois\n")
+	write("c.txt", "quatre\n")
+
+	first, second := filepath.Join(tmp, "run1"), filepath.Join(tmp, "run2")
+	if err := GenerateReport(dir, first); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	write("a.txt", "un deux trois\nquatre\n")
+	os.Remove(filepath.Join(dir, "b.txt"))
+	write("d.txt", "cinq\n")
+	if err := GenerateReport(dir, second); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+
+	d, err := DiffReports(filepath.Join(first, "report.txt"), filepath.Join(second, "report.txt"), tmp)
+	if err != nil {
+		t.Fatalf("diff reports: %v", err)
+	}
+	got := map[string]string{}
+	for _, r := range d.Rows {
+		got[r.File] = r.Status
+	}
+	if len(got) != 3 || got["a.txt"] != "modifie" || got["b.txt"] != "supprime" || got["d.txt"] != "ajoute" || d.Unchanged != 1 {
+		t.Fatalf("rows = %+v, unchanged %d", d.Rows, d.Unchanged)
+	}
+	if d.Rows[0].Old.Words != 2 || d.Rows[0].New.Words != 4 || d.New.Lines != 4 {
+		t.Fatalf("a.txt = %+v, totals %+v", d.Rows[0], d.New)
+	}
+	if _, err := DiffReports(filepath.Join(dir, "a.txt"), filepath.Join(second, "report.txt"), ""); err == nil {
+		t.Fatalf("a plain text file should not parse as a report")
+	}
+}
@@ -0,0 +1,266 @@
+package fileops
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// TextFormat decrit le format d'un fichier texte, detecte pendant la lecture
+type TextFormat struct {
+	Encoding    string
+	BOM         bool
+	CRLF        int    // lignes terminees par \r\n
+	LF          int    // lignes terminees par \n seul
+	Compression string // gzip, bzip2, zstd ou vide
+	Size        int64  // octets lus apres decompression (fichier entier apres scanText)
+}
+
+// LineEndings resume les fins de ligne : LF, CRLF, mixtes ou aucune
+func (t TextFormat) LineEndings() string {
+	switch {
+	case t.CRLF > 0 && t.LF > 0:
+		return fmt.Sprintf("mixtes (CRLF %d, LF %d)", t.CRLF, t.LF)
+	case t.CRLF > 0:
+		return "CRLF (Windows)"
+	case t.LF > 0:
+		return "LF (Unix)"
+	default:
+		return "aucune"
+	}
+}
+
+func (t TextFormat) String() string {
+	if t.BOM {
+		return t.Encoding + " (BOM)"
+	}
+	return t.Encoding
+}
+
+// detectEncoding reconnait un BOM, sinon devine a partir d'un echantillon :
+// octets nuls alternes = UTF-16, UTF-8 valide, sinon Latin-1 (Windows-1252 si
+// l'echantillon contient des octets 0x80-0x9F, inutilises en Latin-1)
+func detectEncoding(sample []byte) (enc string, bomLen int) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8", 3
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return "utf-16le", 2
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return "utf-16be", 2
+	}
+
+	if len(sample) >= 4 {
+		even, odd := 0, 0
+		for i := 0; i+1 < len(sample); i += 2 {
+			if sample[i] == 0 {
+				even++
+			}
+			if sample[i+1] == 0 {
+				odd++
+			}
+		}
+		pairs := len(sample) / 2
+		if odd*3 > pairs && even*10 < pairs {
+			return "utf-16le", 0
+		}
+		if even*3 > pairs && odd*10 < pairs {
+			return "utf-16be", 0
+		}
+	}
+
+	if utf8.Valid(sample) {
+		return "utf-8", 0
+	}
+	// un echantillon plein peut couper un caractere multi-octets en fin
+	if len(sample) == sampleSize {
+		for cut := 1; cut < utf8.UTFMax && cut < len(sample); cut++ {
+			if utf8.RuneStart(sample[len(sample)-cut]) {
+				if utf8.Valid(sample[:len(sample)-cut]) && !utf8.FullRune(sample[len(sample)-cut:]) {
+					return "utf-8", 0
+				}
+				break
+			}
+		}
+	}
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F {
+			return "windows-1252", 0
+		}
+	}
+	return "latin-1", 0
+}
+
+const sampleSize = 64 * 1024
+
+// resolveEncoding applique l'encodage force, ou celui detecte si enc vaut "auto" ;
+// le BOM n'est retire que s'il correspond a l'encodage retenu
+func resolveEncoding(sample []byte, enc string) (string, int) {
+	detected, bomLen := detectEncoding(sample)
+	if enc == "" || enc == "auto" {
+		return detected, bomLen
+	}
+	if enc != detected {
+		bomLen = 0
+	}
+	return enc, bomLen
+}
+
+// openText ouvre un fichier (decompresse si besoin) et renvoie un lecteur UTF-8,
+// BOM retire. enc vaut "auto" pour detecter l'encodage ou force un encodage (--encoding).
+func openText(path, enc string) (*textReader, TextFormat, error) {
+	raw, err := openRaw(path)
+	if err != nil {
+		return nil, TextFormat{}, err
+	}
+	br := bufio.NewReaderSize(raw, sampleSize)
+	sample, _ := br.Peek(sampleSize)
+	enc, bomLen := resolveEncoding(sample, enc)
+	br.Discard(bomLen)
+
+	tf := TextFormat{Encoding: enc, BOM: bomLen > 0, Compression: raw.compression}
+	tr := &textReader{Reader: br, raw: raw}
+	if decode := decoderFor(enc); decode != nil {
+		tr.Reader = &decodeReader{r: br, decode: decode}
+	}
+	return tr, tf, nil
+}
+
+// textReader lit le texte converti ; Size donne les octets lus sur le flux brut
+type textReader struct {
+	io.Reader
+	raw *rawReader
+}
+
+func (t *textReader) Close() error { return t.raw.Close() }
+
+func (t *textReader) Size() int64 { return t.raw.n }
+
+// decoderFor renvoie la conversion vers UTF-8 de enc (nil pour UTF-8)
+func decoderFor(enc string) func([]byte, bool) ([]byte, int) {
+	switch enc {
+	case "utf-16le":
+		return decodeUTF16(false)
+	case "utf-16be":
+		return decodeUTF16(true)
+	case "latin-1":
+		return decodeSingleByte(nil)
+	case "windows-1252":
+		return decodeSingleByte(&cp1252)
+	}
+	return nil
+}
+
+// decodeReader convertit un flux en UTF-8 ; decode renvoie le texte converti et
+// le nombre d'octets consommes (un caractere incomplet attend la suite)
+type decodeReader struct {
+	r      io.Reader
+	decode func(in []byte, atEOF bool) ([]byte, int)
+	buf    [32 * 1024]byte
+	in     []byte
+	out    []byte
+	err    error
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			if len(d.in) == 0 {
+				return 0, d.err
+			}
+			d.out, _ = d.decode(d.in, true)
+			d.in = nil
+			continue
+		}
+		n, err := d.r.Read(d.buf[:])
+		d.in = append(d.in, d.buf[:n]...)
+		d.err = err
+		out, used := d.decode(d.in, false)
+		d.out = out
+		d.in = d.in[used:]
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func decodeUTF16(bigEndian bool) func([]byte, bool) ([]byte, int) {
+	unit := func(b []byte) uint16 {
+		if bigEndian {
+			return uint16(b[0])<<8 | uint16(b[1])
+		}
+		return uint16(b[1])<<8 | uint16(b[0])
+	}
+	return func(in []byte, atEOF bool) ([]byte, int) {
+		var out []byte
+		i := 0
+		for i+1 < len(in) {
+			u := unit(in[i:])
+			if utf16.IsSurrogate(rune(u)) {
+				if i+3 >= len(in) {
+					if !atEOF {
+						break
+					}
+					out = utf8.AppendRune(out, utf8.RuneError)
+					i += 2
+					continue
+				}
+				r := utf16.DecodeRune(rune(u), rune(unit(in[i+2:])))
+				if r == utf8.RuneError {
+					out = utf8.AppendRune(out, r)
+					i += 2
+					continue
+				}
+				out = utf8.AppendRune(out, r)
+				i += 4
+				continue
+			}
+			out = utf8.AppendRune(out, rune(u))
+			i += 2
+		}
+		if atEOF && i < len(in) {
+			out = utf8.AppendRune(out, utf8.RuneError)
+			i = len(in)
+		}
+		return out, i
+	}
+}
+
+// decodeSingleByte : Latin-1 (table nil) ou Windows-1252 (0x80-0x9F remappes)
+func decodeSingleByte(table *[32]rune) func([]byte, bool) ([]byte, int) {
+	return func(in []byte, _ bool) ([]byte, int) {
+		out := make([]byte, 0, len(in)+len(in)/2)
+		for _, b := range in {
+			r := rune(b)
+			if table != nil && b >= 0x80 && b <= 0x9F {
+				r = table[b-0x80]
+			}
+			out = utf8.AppendRune(out, r)
+		}
+		return out, len(in)
+	}
+}
+
+// Windows-1252 pour 0x80-0x9F (les positions non definies gardent le controle Latin-1)
+var cp1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+// decodeString convertit un morceau d'un encodage mono-octet (tail)
+func decodeString(s, enc string) string {
+	switch enc {
+	case "latin-1":
+		out, _ := decodeSingleByte(nil)([]byte(s), true)
+		return string(out)
+	case "windows-1252":
+		out, _ := decodeSingleByte(&cp1252)([]byte(s), true)
+		return string(out)
+	}
+	return s
+}
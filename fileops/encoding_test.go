@@ -0,0 +1,79 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16LE(s string, bom bool) []byte {
+	var out []byte
+	if bom {
+		out = append(out, 0xFF, 0xFE)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+func TestDetectEncoding(t *testing.T) {
+	cases := []struct {
+		data []byte
+		enc  string
+		bom  int
+	}{
+		{[]byte("\xEF\xBB\xBFhello"), "utf-8", 3},
+		{utf16LE("hello", true), "utf-16le", 2},
+		{utf16LE("hello world", false), "utf-16le", 0},
+		{[]byte("caf\xc3\xa9"), "utf-8", 0},
+		{[]byte("caf\xe9"), "latin-1", 0},
+		{[]byte("\x93quoted\x94 caf\xe9"), "windows-1252", 0},
+	}
+	for _, c := range cases {
+		enc, bom := detectEncoding(c.data)
+		if enc != c.enc || bom != c.bom {
+			t.Fatalf("detect(%q) = %s/%d, want %s/%d", c.data, enc, bom, c.enc, c.bom)
+		}
+	}
+}
+
+func TestAnalyzeUTF16WithCRLF(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "export.txt")
+	if err := os.WriteFile(in, utf16LE("élève 😀 un\r\ndeux élèves\r\n", true), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	st, err := Analyze(in, AnalyzeOptions{N: 1, OutDir: tmp})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if st.Format.Encoding != "utf-16le" || !st.Format.BOM || st.Format.CRLF != 2 || st.Format.LF != 0 {
+		t.Fatalf("unexpected format: %+v", st.Format)
+	}
+	if st.Lines != 2 || st.Text.Words != 4 || !reflect.DeepEqual(st.Head, []string{"élève 😀 un"}) {
+		t.Fatalf("unexpected stats: lines=%d words=%d head=%q", st.Lines, st.Text.Words, st.Head)
+	}
+	tail, err := tailLines(in, 1)
+	if err != nil || !reflect.DeepEqual(tail, []string{"deux élèves"}) {
+		t.Fatalf("tail = %q, %v", tail, err)
+	}
+}
+
+func TestTailLatin1ConvertsToUTF8(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "legacy.txt")
+	if err := os.WriteFile(in, []byte("ligne 1\ncaf\xe9\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := Tail(in, 1, tmp); err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	out, _ := os.ReadFile(filepath.Join(tmp, "tail.txt"))
+	if string(out) != "café\n" {
+		t.Fatalf("tail.txt = %q", out)
+	}
+}
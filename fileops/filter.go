@@ -0,0 +1,398 @@
+package fileops
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	hlStart = "\033[1;31m"
+	hlEnd   = "\033[0m"
+)
+
+// FilterOptions decrit un filtre de lignes. Expr combine des motifs avec AND,
+// OR et NOT (en majuscules, entoures d'espaces) : "error AND NOT debug",
+// "warn OR error". Les motifs NOT excluent toujours la ligne.
+type FilterOptions struct {
+	Expr          string
+	Regex         bool // motifs = expressions regulieres (sinon texte exact)
+	CaseSensitive bool
+	WholeWord     bool
+	Before        int  // lignes de contexte avant chaque correspondance (grep -B)
+	After         int  // lignes de contexte apres (grep -A)
+	Show          int  // lignes gardees pour l'affichage terminal
+	Color         bool // surligne les correspondances affichees
+}
+
+// Matcher applique une expression de filtre a une ligne
+type Matcher struct {
+	include   []*regexp.Regexp
+	exclude   []*regexp.Regexp
+	all       bool
+	wholeWord bool
+}
+
+// NewMatcher compile l'expression de o
+func NewMatcher(o FilterOptions) (*Matcher, error) {
+	expr := strings.TrimSpace(o.Expr)
+	if expr == "" {
+		return nil, fmt.Errorf("filtre vide")
+	}
+	hasAnd, hasOr := strings.Contains(expr, " AND "), strings.Contains(expr, " OR ")
+	if hasAnd && hasOr {
+		return nil, fmt.Errorf("filtre %q: AND et OR ne peuvent pas etre melanges", expr)
+	}
+	sep := " OR "
+	if hasAnd {
+		sep = " AND "
+	}
+
+	m := &Matcher{all: hasAnd, wholeWord: o.WholeWord}
+	for _, term := range strings.Split(expr, sep) {
+		term = strings.TrimSpace(term)
+		negate := false
+		if strings.HasPrefix(term, "NOT ") {
+			negate = true
+			term = strings.TrimSpace(strings.TrimPrefix(term, "NOT "))
+		}
+		if term == "" {
+			return nil, fmt.Errorf("filtre %q: motif vide", expr)
+		}
+		pattern := term
+		if !o.Regex {
+			pattern = regexp.QuoteMeta(term)
+		}
+		if !o.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("motif %q invalide: %w", term, err)
+		}
+		if negate {
+			m.exclude = append(m.exclude, re)
+		} else {
+			m.include = append(m.include, re)
+		}
+	}
+	return m, nil
+}
+
+// Match indique si la ligne est retenue
+func (m *Matcher) Match(line string) bool {
+	for _, re := range m.exclude {
+		if m.find(re, line) != nil {
+			return false
+		}
+	}
+	if len(m.include) == 0 {
+		return true
+	}
+	for _, re := range m.include {
+		found := m.find(re, line) != nil
+		if found && !m.all {
+			return true
+		}
+		if !found && m.all {
+			return false
+		}
+	}
+	return m.all
+}
+
+// find renvoie les indices (sous-groupes compris) de la premiere correspondance
+// valide ; en mode mot entier, elle doit etre bornee par des non-lettres
+func (m *Matcher) find(re *regexp.Regexp, line string) []int {
+	for _, loc := range re.FindAllStringSubmatchIndex(line, -1) {
+		if !m.wholeWord || isWordBoundary(line, loc[0], loc[1]) {
+			return loc
+		}
+	}
+	return nil
+}
+
+func isWordBoundary(line string, start, end int) bool {
+	if start == end {
+		return false
+	}
+	if r, _ := utf8.DecodeLastRuneInString(line[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(line[end:]); end < len(line) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Highlight entoure les correspondances des motifs inclus de codes couleur ANSI
+func (m *Matcher) Highlight(line string) string {
+	var spans [][2]int
+	for _, re := range m.include {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] < loc[1] && (!m.wholeWord || isWordBoundary(line, loc[0], loc[1])) {
+				spans = append(spans, [2]int{loc[0], loc[1]})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return line
+	}
+	// fusion des zones qui se chevauchent (plusieurs motifs)
+	marked := make([]bool, len(line))
+	for _, s := range spans {
+		for i := s[0]; i < s[1]; i++ {
+			marked[i] = true
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(hlStart)
+		}
+		b.WriteByte(line[i])
+		if marked[i] && (i == len(line)-1 || !marked[i+1]) {
+			b.WriteString(hlEnd)
+		}
+	}
+	return b.String()
+}
+
+// CaptureNames liste les groupes nommes des motifs inclus, dans l'ordre
+func (m *Matcher) CaptureNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, re := range m.include {
+		for _, name := range re.SubexpNames() {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Captures renvoie les groupes nommes trouves dans la ligne
+func (m *Matcher) Captures(line string) map[string]string {
+	out := map[string]string{}
+	for _, re := range m.include {
+		loc := m.find(re, line)
+		if loc == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name == "" || loc[2*i] < 0 {
+				continue
+			}
+			if _, ok := out[name]; !ok {
+				out[name] = line[loc[2*i]:loc[2*i+1]]
+			}
+		}
+	}
+	return out
+}
+
+// FilterResult resume un filtrage
+type FilterResult struct {
+	Lines    int
+	Matches  int
+	Written  int      // lignes ecrites dans Output : correspondances et contexte
+	Shown    []string // lignes affichables, "12:" correspondance, "11-" contexte
+	Output   string   // fichier des lignes retenues
+	Rejected string   // fichier des autres lignes
+	Captures string   // CSV des groupes nommes (vide si aucun groupe)
+}
+
+// Filter ecrit dans outDir les lignes retenues (filtered.txt, avec contexte),
+// les autres (filtered_not.txt) et, si les motifs ont des groupes nommes,
+// leurs valeurs dans filtered_captures.csv. Le fichier est lu en flux.
+func Filter(path, outDir string, o FilterOptions) (*FilterResult, error) {
+	m, err := NewMatcher(o)
+	if err != nil {
+		return nil, err
+	}
+	lf, err := newLineFilter(m, o, outDir)
+	if err != nil {
+		return nil, err
+	}
+	defer lf.close()
+	err = scanLines(path, func(line string) bool {
+		lf.feed(line)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := lf.close(); err != nil {
+		return nil, err
+	}
+	return &lf.res, nil
+}
+
+// PrintFilterResult affiche le resume d'un filtrage et les lignes gardees
+func PrintFilterResult(res *FilterResult) {
+	for _, line := range res.Shown {
+		fmt.Printf("  %s\n", line)
+	}
+	if len(res.Shown) > 0 && len(res.Shown) < res.Matches {
+		fmt.Println("  ...")
+	}
+	if res.Written > res.Matches {
+		fmt.Printf("  -> %d lignes dans %s (%d correspondances, %d de contexte)\n", res.Written, res.Output, res.Matches, res.Written-res.Matches)
+	} else {
+		fmt.Printf("  -> %d lignes dans %s\n", res.Matches, res.Output)
+	}
+	fmt.Printf("  -> %d lignes dans %s\n", res.Lines-res.Written, res.Rejected)
+	if res.Captures != "" {
+		fmt.Printf("  -> groupes nommes dans %s\n", res.Captures)
+	}
+}
+
+type numberedLine struct {
+	n    int
+	text string
+}
+
+// lineFilter trie les lignes au fil de la lecture, avec le contexte facon grep
+type lineFilter struct {
+	m             *Matcher
+	o             FilterOptions
+	with, without *lineWriter
+	csvFile       *outputFile
+	csv           *csv.Writer
+	names         []string
+
+	before    []numberedLine
+	afterLeft int
+	lastOut   int
+	res       FilterResult
+}
+
+func newLineFilter(m *Matcher, o FilterOptions, outDir string) (*lineFilter, error) {
+	lf := &lineFilter{m: m, o: o}
+	var err error
+	if lf.with, err = newLineWriter(filepath.Join(outDir, "filtered.txt")); err != nil {
+		return nil, err
+	}
+	if lf.without, err = newLineWriter(filepath.Join(outDir, "filtered_not.txt")); err != nil {
+		lf.close()
+		return nil, err
+	}
+	lf.res.Output, lf.res.Rejected = lf.with.Path, lf.without.Path
+	if lf.names = m.CaptureNames(); len(lf.names) > 0 {
+		if lf.csvFile, err = createOutput(filepath.Join(outDir, "filtered_captures.csv")); err != nil {
+			lf.close()
+			return nil, err
+		}
+		lf.csv = csv.NewWriter(lf.csvFile)
+		lf.csv.Write(append([]string{"line"}, lf.names...))
+		lf.res.Captures = lf.csvFile.Path
+	}
+	return lf, nil
+}
+
+func (lf *lineFilter) feed(line string) bool {
+	lf.res.Lines++
+	n := lf.res.Lines
+	if !lf.m.Match(line) {
+		switch {
+		case lf.afterLeft > 0:
+			lf.afterLeft--
+			lf.emit(n, line, false)
+		case lf.o.Before > 0:
+			// en attente : contexte si une correspondance suit, sinon filtered_not.txt
+			lf.before = append(lf.before, numberedLine{n, line})
+			if len(lf.before) > lf.o.Before {
+				lf.without.WriteLine(lf.before[0].text)
+				lf.before = lf.before[1:]
+			}
+		default:
+			lf.without.WriteLine(line)
+		}
+		return false
+	}
+
+	lf.res.Matches++
+	for _, b := range lf.before {
+		lf.emit(b.n, b.text, false)
+	}
+	lf.before = lf.before[:0]
+	lf.emit(n, line, true)
+	lf.afterLeft = lf.o.After
+
+	if lf.csv != nil {
+		caps := lf.m.Captures(line)
+		row := []string{strconv.Itoa(n)}
+		for _, name := range lf.names {
+			row = append(row, caps[name])
+		}
+		lf.csv.Write(row)
+	}
+	return true
+}
+
+func (lf *lineFilter) emit(n int, line string, match bool) {
+	// separateur entre deux blocs non contigus, comme grep
+	if (lf.o.Before > 0 || lf.o.After > 0) && lf.lastOut > 0 && n > lf.lastOut+1 {
+		lf.with.WriteLine("--")
+		lf.show("--")
+	}
+	lf.with.WriteLine(line)
+	lf.res.Written++
+	lf.lastOut = n
+
+	sep := "-"
+	if match {
+		sep = ":"
+		if lf.o.Color {
+			line = lf.m.Highlight(line)
+		}
+	}
+	lf.show(strconv.Itoa(n) + sep + line)
+}
+
+func (lf *lineFilter) show(s string) {
+	if len(lf.res.Shown) < lf.o.Show {
+		lf.res.Shown = append(lf.res.Shown, s)
+	}
+}
+
+// close ecrit les dernieres lignes en attente de contexte et ferme les fichiers ;
+// filtered.txt et filtered_not.txt se partagent alors toutes les lignes
+func (lf *lineFilter) close() error {
+	if lf.without != nil {
+		for _, b := range lf.before {
+			lf.without.WriteLine(b.text)
+		}
+	}
+	lf.before = nil
+	var err error
+	for _, w := range []*lineWriter{lf.with, lf.without} {
+		if w != nil {
+			if cerr := w.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if lf.csvFile != nil {
+		lf.csv.Flush()
+		if cerr := lf.csv.Error(); err == nil {
+			err = cerr
+		}
+		if cerr := lf.csvFile.Close(); err == nil {
+			err = cerr
+		}
+		lf.csvFile = nil
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcherExpressions(t *testing.T) {
+	cases := []struct {
+		opts FilterOptions
+		line string
+		want bool
+	}{
+		{FilterOptions{Expr: "error"}, "An ERROR occurred", true},
+		{FilterOptions{Expr: "error", CaseSensitive: true}, "An ERROR occurred", false},
+		{FilterOptions{Expr: "error AND timeout"}, "error: timeout", true},
+		{FilterOptions{Expr: "error AND timeout"}, "error: refused", false},
+		{FilterOptions{Expr: "warn OR error"}, "warn: disk", true},
+		{FilterOptions{Expr: "error AND NOT debug"}, "debug error", false},
+		{FilterOptions{Expr: "NOT debug"}, "info start", true},
+		{FilterOptions{Expr: "err", WholeWord: true}, "error", false},
+		{FilterOptions{Expr: "été", WholeWord: true}, "l'été dernier", true},
+		{FilterOptions{Expr: `\d{3} ms`, Regex: true}, "took 250 ms", true},
+		{FilterOptions{Expr: "a.c"}, "abc", false},
+	}
+	for _, c := range cases {
+		m, err := NewMatcher(c.opts)
+		if err != nil {
+			t.Fatalf("matcher %q: %v", c.opts.Expr, err)
+		}
+		if got := m.Match(c.line); got != c.want {
+			t.Fatalf("Match(%q, %q) = %v, want %v", c.opts.Expr, c.line, got, c.want)
+		}
+	}
+
+	if _, err := NewMatcher(FilterOptions{Expr: "a AND b OR c"}); err == nil {
+		t.Fatal("expected error when mixing AND and OR")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	m, _ := NewMatcher(FilterOptions{Expr: "ab OR bc"})
+	if got := m.Highlight("xabcx"); got != "x"+hlStart+"abc"+hlEnd+"x" {
+		t.Fatalf("unexpected highlight: %q", got)
+	}
+}
+
+func TestFilterContextAndCaptures(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "app.log")
+	content := "start\nuser=alice status=500\nok\nok\nok\nuser=bob status=503\nend\n"
+	if err := os.WriteFile(in, []byte(content), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	res, err := Filter(in, tmp, FilterOptions{
+		Expr:   `user=(?P<user>\w+) status=(?P<status>5\d\d)`,
+		Regex:  true,
+		Before: 1,
+		Show:   10,
+	})
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	if res.Matches != 2 || res.Lines != 7 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	filtered, _ := os.ReadFile(filepath.Join(tmp, "filtered.txt"))
+	want := "start\nuser=alice status=500\n--\nok\nuser=bob status=503\n"
+	if string(filtered) != want {
+		t.Fatalf("unexpected filtered.txt:\n%s", filtered)
+	}
+	if rejected, _ := os.ReadFile(filepath.Join(tmp, "filtered_not.txt")); string(rejected) != "ok\nok\nend\n" {
+		t.Fatalf("context lines should not be in filtered_not.txt:\n%s", rejected)
+	}
+	if res.Written != 4 {
+		t.Fatalf("written = %d, want 4 (2 matches + 2 context)", res.Written)
+	}
+	if res.Shown[1] != "2:user=alice status=500" || res.Shown[3] != "5-ok" {
+		t.Fatalf("unexpected shown lines: %q", res.Shown)
+	}
+
+	csv, _ := os.ReadFile(filepath.Join(tmp, "filtered_captures.csv"))
+	if string(csv) != "line,user,status\n2,alice,500\n6,bob,503\n" {
+		t.Fatalf("unexpected captures:\n%s", csv)
+	}
+}
@@ -0,0 +1,323 @@
+package fileops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FollowOptions regle le suivi de fichiers facon tail -F
+type FollowOptions struct {
+	Filter FilterOptions // Expr vide = toutes les lignes ; contexte -B/-A garde
+	Lines  int           // dernieres lignes affichees au demarrage
+	Poll   time.Duration // intervalle de verification des fichiers
+	Once   bool          // un seul passage, sans suivi : un fichier absent est une erreur
+}
+
+// Follow affiche les lignes ajoutees aux fichiers jusqu'a l'annulation de ctx.
+// Il survit a la rotation : fichier tronque (relu depuis le debut) ou renomme
+// puis recree (fin de l'ancien lue, puis nouveau suivi). Avec plusieurs fichiers,
+// chaque ligne est prefixee par le nom du fichier. Avec Once, les fichiers
+// lisibles sont affiches et la premiere erreur d'ouverture est renvoyee.
+func Follow(ctx context.Context, paths []string, o FollowOptions, w io.Writer) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("aucun fichier a suivre")
+	}
+	var m *Matcher
+	if strings.TrimSpace(o.Filter.Expr) != "" {
+		var err error
+		if m, err = NewMatcher(o.Filter); err != nil {
+			return err
+		}
+	}
+	if o.Poll <= 0 {
+		o.Poll = time.Duration(settings.FollowPollMs) * time.Millisecond
+	}
+
+	prefixes := followPrefixes(paths)
+	followers := make([]*follower, len(paths))
+	var firstErr error
+	for i, p := range paths {
+		fl := &follower{path: p, prefix: prefixes[i], m: m, o: o.Filter, w: w}
+		followers[i] = fl
+		if err := fl.start(o.Lines); err != nil {
+			if o.Once {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			fl.notice("en attente (%v)", err)
+		}
+	}
+	defer func() {
+		for _, fl := range followers {
+			fl.flush()
+			fl.close()
+		}
+	}()
+	if o.Once {
+		return firstErr
+	}
+
+	ticker := time.NewTicker(o.Poll)
+	defer ticker.Stop()
+	for {
+		for _, fl := range followers {
+			fl.poll()
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// followPrefixes renvoie "nom | " aligne pour chaque fichier (rien s'il est seul) ;
+// le chemin complet sert si deux fichiers portent le meme nom
+func followPrefixes(paths []string) []string {
+	out := make([]string, len(paths))
+	if len(paths) == 1 {
+		return out
+	}
+	names := make([]string, len(paths))
+	seen := map[string]bool{}
+	unique := true
+	for i, p := range paths {
+		names[i] = filepath.Base(p)
+		if seen[names[i]] {
+			unique = false
+		}
+		seen[names[i]] = true
+	}
+	if !unique {
+		copy(names, paths)
+	}
+	width := 0
+	for _, n := range names {
+		width = max(width, len([]rune(n)))
+	}
+	for i, n := range names {
+		out[i] = fmt.Sprintf("%-*s | ", width, n)
+	}
+	return out
+}
+
+// follower suit un fichier : offset lu, encodage et ligne en cours
+type follower struct {
+	path   string
+	prefix string
+	m      *Matcher
+	o      FilterOptions
+	w      io.Writer
+
+	f       *os.File
+	offset  int64
+	decode  func([]byte, bool) ([]byte, int)
+	raw     []byte // octets pas encore decodes (caractere coupe)
+	partial string // debut de ligne sans saut de ligne
+
+	before    []string
+	afterLeft int
+	skipped   int  // lignes non affichees depuis la derniere affichee
+	shown     bool // un bloc a deja ete affiche (separateur --)
+}
+
+// start ouvre le fichier, affiche ses n dernieres lignes et se place a la fin
+func (fl *follower) start(n int) error {
+	if n > 0 {
+		lines, err := tailLines(fl.path, n)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			fl.line(line)
+		}
+	}
+	if err := fl.open(); err != nil {
+		return err
+	}
+	info, err := fl.f.Stat()
+	if err != nil {
+		return err
+	}
+	fl.offset = max(fl.offset, info.Size())
+	return nil
+}
+
+// open ouvre le fichier depuis le debut (BOM saute) et detecte son encodage
+func (fl *follower) open() error {
+	f, err := os.Open(fl.path)
+	if err != nil {
+		return fmt.Errorf("impossible d'ouvrir %s: %w", fl.path, err)
+	}
+	head := make([]byte, headerLen)
+	n, _ := f.ReadAt(head, 0)
+	if c := detectCompression(head[:n]); c != "" {
+		f.Close()
+		return fmt.Errorf("%s est compresse (%s), suivi impossible", fl.path, c)
+	}
+	fl.close()
+	fl.f = f
+	fl.rewind()
+	return nil
+}
+
+// rewind repart du debut du fichier courant
+func (fl *follower) rewind() {
+	sample := make([]byte, sampleSize)
+	n, _ := fl.f.ReadAt(sample, 0)
+	enc, bomLen := resolveEncoding(sample[:n], settings.Encoding)
+	fl.decode = decoderFor(enc)
+	fl.offset = int64(bomLen)
+	fl.raw, fl.partial = nil, ""
+}
+
+func (fl *follower) close() {
+	if fl.f != nil {
+		fl.f.Close()
+		fl.f = nil
+	}
+}
+
+// poll lit les ajouts et detecte rotation et troncature
+func (fl *follower) poll() {
+	info, statErr := os.Stat(fl.path)
+	if fl.f == nil {
+		if statErr != nil {
+			return
+		}
+		if err := fl.open(); err != nil {
+			return
+		}
+		fl.notice("fichier apparu, suivi depuis le debut")
+	} else if statErr != nil || !sameFile(fl.f, info) {
+		// renomme (ou supprime) : on finit l'ancien fichier avant de passer au nouveau
+		fl.read()
+		fl.flush()
+		if statErr != nil {
+			return
+		}
+		if err := fl.open(); err != nil {
+			return
+		}
+		fl.notice("rotation detectee, nouveau fichier suivi")
+	} else if info.Size() < fl.offset {
+		fl.flush()
+		fl.rewind()
+		fl.notice("fichier tronque, relu depuis le debut")
+	}
+	fl.read()
+}
+
+func sameFile(f *os.File, info os.FileInfo) bool {
+	cur, err := f.Stat()
+	return err == nil && os.SameFile(cur, info)
+}
+
+// read traite les octets ajoutes depuis offset ; une ligne sans saut de ligne
+// attend la suite (ou flush)
+func (fl *follower) read() {
+	if fl.f == nil {
+		return
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := fl.f.ReadAt(buf, fl.offset)
+		if n > 0 {
+			fl.offset += int64(n)
+			fl.feed(buf[:n])
+		}
+		if err != nil || n == 0 {
+			return
+		}
+	}
+}
+
+func (fl *follower) feed(data []byte) {
+	text := string(data)
+	if fl.decode != nil {
+		fl.raw = append(fl.raw, data...)
+		out, used := fl.decode(fl.raw, false)
+		fl.raw = fl.raw[used:]
+		text = string(out)
+	}
+	text = fl.partial + text
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			break
+		}
+		fl.line(strings.TrimSuffix(text[:i], "\r"))
+		text = text[i+1:]
+	}
+	// protection contre un fichier sans saut de ligne
+	if len(text) > maxLineSize {
+		fl.line(text)
+		text = ""
+	}
+	fl.partial = text
+}
+
+// flush affiche la ligne en cours, meme sans saut de ligne
+func (fl *follower) flush() {
+	if fl.decode != nil && len(fl.raw) > 0 {
+		out, _ := fl.decode(fl.raw, true)
+		fl.partial += string(out)
+		fl.raw = nil
+	}
+	if fl.partial != "" {
+		fl.line(strings.TrimSuffix(fl.partial, "\r"))
+		fl.partial = ""
+	}
+}
+
+// line applique le filtre, avec le contexte facon grep
+func (fl *follower) line(line string) {
+	if fl.m == nil {
+		fl.print(line)
+		return
+	}
+	if !fl.m.Match(line) {
+		if fl.afterLeft > 0 {
+			fl.afterLeft--
+			fl.print(line)
+			return
+		}
+		fl.skipped++
+		if fl.o.Before > 0 {
+			fl.before = append(fl.before, line)
+			if len(fl.before) > fl.o.Before {
+				fl.before = fl.before[1:]
+			}
+		}
+		return
+	}
+	// separateur entre deux blocs non contigus, comme grep
+	if fl.shown && fl.skipped > len(fl.before) && (fl.o.Before > 0 || fl.o.After > 0) {
+		fl.print("--")
+	}
+	for _, b := range fl.before {
+		fl.print(b)
+	}
+	fl.before = fl.before[:0]
+	if fl.o.Color {
+		line = fl.m.Highlight(line)
+	}
+	fl.print(line)
+	fl.afterLeft = fl.o.After
+}
+
+func (fl *follower) print(line string) {
+	fmt.Fprintln(fl.w, fl.prefix+line)
+	fl.skipped, fl.shown = 0, true
+}
+
+func (fl *follower) notice(format string, args ...any) {
+	fmt.Fprintf(fl.w, "%s== %s: %s ==\n", fl.prefix, fl.path, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,169 @@
+package fileops
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer est lu par le test pendant que Follow ecrit
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor attend que la sortie contienne want
+func waitFor(t *testing.T, out *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %q, got:\n%s", want, out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+}
+
+func startFollow(t *testing.T, paths []string, o FollowOptions) *syncBuffer {
+	t.Helper()
+	o.Poll = 5 * time.Millisecond
+	out := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Follow(ctx, paths, o, out) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("follow: %v", err)
+		}
+	})
+	return out
+}
+
+func TestFollowRotation(t *testing.T) {
+	tmp := t.TempDir()
+	log := filepath.Join(tmp, "app.log")
+	if err := os.WriteFile(log, []byte("old 1\nold 2\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out := startFollow(t, []string{log}, FollowOptions{Lines: 1})
+	waitFor(t, out, "old 2\n")
+
+	appendFile(t, log, "new 1\npart")
+	waitFor(t, out, "new 1\n")
+	appendFile(t, log, "ial\n")
+	waitFor(t, out, "partial\n")
+
+	// troncature (logrotate copytruncate)
+	if err := os.Truncate(log, 0); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	waitFor(t, out, "tronque")
+	appendFile(t, log, "after truncate\n")
+	waitFor(t, out, "after truncate\n")
+
+	// renommage puis recreation : la fin de l'ancien fichier n'est pas perdue
+	appendFile(t, log, "last of old\n")
+	if err := os.Rename(log, log+".1"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	appendFile(t, log+".1", "late write\n")
+	appendFile(t, log, "first of new\n")
+	waitFor(t, out, "first of new\n")
+
+	got := out.String()
+	if strings.Contains(got, "old 1") {
+		t.Fatalf("only the last line should be shown at start:\n%s", got)
+	}
+	for _, want := range []string{"last of old\n", "late write\n", "rotation"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "late write") > strings.Index(got, "first of new") {
+		t.Fatalf("old file should be drained before the new one:\n%s", got)
+	}
+}
+
+func TestFollowFilterAndPrefixes(t *testing.T) {
+	tmp := t.TempDir()
+	a, b := filepath.Join(tmp, "a.log"), filepath.Join(tmp, "bb.log")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("start error\n"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	out := startFollow(t, []string{a, b}, FollowOptions{Filter: FilterOptions{Expr: "error AND NOT debug"}, Lines: 1})
+	waitFor(t, out, "bb.log | start error\n")
+	appendFile(t, a, "info ok\nerror disk\ndebug error\n")
+	appendFile(t, b, "ERROR net\n")
+	waitFor(t, out, "ERROR net\n")
+	waitFor(t, out, "error disk\n")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{"a.log  | start error", "bb.log | start error", "a.log  | error disk", "bb.log | ERROR net"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestFollowContextSeparators(t *testing.T) {
+	var out bytes.Buffer
+	m, _ := NewMatcher(FilterOptions{Expr: "x"})
+	fl := &follower{m: m, o: FilterOptions{Before: 1}, w: &out}
+	for _, l := range []string{"a", "x1", "b", "x2", "c", "d", "x3"} {
+		fl.line(l)
+	}
+	if got := out.String(); got != "a\nx1\nb\nx2\n--\nd\nx3\n" {
+		t.Fatalf("output = %q", got)
+	}
+}
+
+func TestFollowOnceMissingFile(t *testing.T) {
+	tmp := t.TempDir()
+	ok, missing := filepath.Join(tmp, "ok.log"), filepath.Join(tmp, "missing.log")
+	if err := os.WriteFile(ok, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var out strings.Builder
+	err := Follow(context.Background(), []string{ok, missing}, FollowOptions{Lines: 1, Once: true}, &out)
+	if err == nil || !strings.Contains(err.Error(), "missing.log") {
+		t.Fatalf("expected open error for missing.log, got %v", err)
+	}
+	if got := out.String(); got != "ok.log      | b\n" {
+		t.Fatalf("output = %q", got)
+	}
+}
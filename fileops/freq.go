@@ -0,0 +1,182 @@
+package fileops
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gotools/config"
+	"gotools/tokenizer"
+)
+
+// WordCount est un mot et son nombre d'occurrences
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// FreqOptions regle la frequence des mots ; Language "fr"/"en" ignore les mots
+// vides de la langue, une autre valeur les garde tous
+type FreqOptions struct {
+	Language string
+	TopN     int
+	Stem     bool
+}
+
+// freqOptions renvoie les options de la config ; main remplace "auto" par
+// wiki_lang, a defaut on prend la langue par defaut de la config
+func freqOptions() FreqOptions {
+	lang := settings.Language
+	if lang == "auto" {
+		lang = config.DefaultConfig().WikiLang
+	}
+	return FreqOptions{Language: lang, TopN: settings.TopWords, Stem: settings.Stemming}
+}
+
+// wordCounter compte les mots normalises au fil des lignes
+type wordCounter struct {
+	o      FreqOptions
+	counts map[string]int
+	total  int // mots comptes (hors mots vides)
+}
+
+func newWordCounter(o FreqOptions) *wordCounter {
+	return &wordCounter{o: o, counts: map[string]int{}}
+}
+
+func (wc *wordCounter) add(line string) {
+	for _, w := range tokenizer.Words(line) {
+		w = normalizeWord(w)
+		if w == "" || isStopword(wc.o.Language, w) {
+			continue
+		}
+		if wc.o.Stem {
+			w = stem(wc.o.Language, w)
+		}
+		wc.counts[w]++
+		wc.total++
+	}
+}
+
+// top renvoie les n mots les plus frequents (ex aequo par ordre alphabetique)
+func (wc *wordCounter) top(n int) []WordCount {
+	out := make([]WordCount, 0, len(wc.counts))
+	for w, c := range wc.counts {
+		out = append(out, WordCount{w, c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Word < out[j].Word
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// normalizeWord met en minuscules et retire les elisions francaises (l', d', qu'...)
+func normalizeWord(w string) string {
+	w = strings.ToLower(strings.ReplaceAll(w, "’", "'"))
+	if i := strings.IndexByte(w, '\''); i > 0 {
+		switch w[:i] {
+		case "l", "d", "j", "m", "n", "s", "t", "c", "qu", "jusqu", "lorsqu", "puisqu":
+			w = w[i+1:]
+		}
+	}
+	return w
+}
+
+// stem applique une racinisation legere : suffixes flexionnels les plus courants,
+// sans dictionnaire (les racines restent lisibles mais ne sont pas toujours des mots)
+func stem(lang, w string) string {
+	var suffixes []string
+	switch lang {
+	case "fr":
+		suffixes = []string{"issements", "issement", "ements", "ement", "ations", "ation", "euses", "euse", "eaux", "ées", "ée", "aux", "es", "s", "x", "e"}
+	case "en":
+		suffixes = []string{"ational", "ations", "ation", "fulness", "ness", "ments", "ment", "ingly", "ings", "ing", "edly", "ies", "ied", "ed", "ly", "es", "s"}
+	default:
+		return w
+	}
+	for _, suf := range suffixes {
+		if strings.HasSuffix(w, suf) && len([]rune(w))-len([]rune(suf)) >= 3 {
+			if lang == "en" && (suf == "s" && strings.HasSuffix(w, "ss")) {
+				continue
+			}
+			return strings.TrimSuffix(w, suf)
+		}
+	}
+	return w
+}
+
+// WordFrequency compte les mots d'un ou plusieurs fichiers, lus en flux
+func WordFrequency(paths []string, o FreqOptions) ([]WordCount, int, error) {
+	wc := newWordCounter(o)
+	for _, p := range paths {
+		err := scanLines(p, func(line string) bool {
+			wc.add(line)
+			return true
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return wc.top(o.TopN), wc.total, nil
+}
+
+// FrequencyReport calcule la frequence des mots de tous les fichiers du dossier,
+// l'affiche et ecrit le classement complet dans outDir/word_freq.csv
+func FrequencyReport(dir, outDir string) error {
+	files, err := FindTxtFiles(dir)
+	if err != nil {
+		return err
+	}
+	o := freqOptions()
+	all := o
+	all.TopN = 0
+	words, total, err := WordFrequency(files, all)
+	if err != nil {
+		return err
+	}
+
+	out, err := createOutput(filepath.Join(outDir, "word_freq.csv"))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.Write([]string{"word", "count"})
+	for _, wc := range words {
+		w.Write([]string{wc.Word, strconv.Itoa(wc.Count)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if len(words) > o.TopN {
+		words = words[:o.TopN]
+	}
+	fmt.Printf("  %d fichiers, %d mots comptes (langue: %s)\n", len(files), total, o.Language)
+	printTopWords(words, total)
+	fmt.Printf("  -> Classement complet dans %s\n", out.Path)
+	return nil
+}
+
+func printTopWords(words []WordCount, total int) {
+	for i, wc := range words {
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(wc.Count) / float64(total)
+		}
+		fmt.Printf("  %3d. %-20s %6d  %5.1f%%\n", i+1, wc.Word, wc.Count, pct)
+	}
+}
@@ -0,0 +1,64 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWordFrequencyStopwords(t *testing.T) {
+	tmp := t.TempDir()
+	a := filepath.Join(tmp, "a.txt")
+	b := filepath.Join(tmp, "b.txt")
+	if err := os.WriteFile(a, []byte("Le chat et le chien.\nL'été, le CHAT dort!\n"), 0644); err != nil {
+		t.Fatalf("write a: %v", err)
+	}
+	if err := os.WriteFile(b, []byte("Un chat, 42 chiens.\n"), 0644); err != nil {
+		t.Fatalf("write b: %v", err)
+	}
+
+	words, total, err := WordFrequency([]string{a, b}, FreqOptions{Language: "fr", TopN: 3})
+	if err != nil {
+		t.Fatalf("frequency: %v", err)
+	}
+	want := []WordCount{{"chat", 3}, {"chien", 1}, {"chiens", 1}}
+	if !reflect.DeepEqual(words, want) || total != 6 {
+		t.Fatalf("got %v (total %d), want %v", words, total, want)
+	}
+
+	words, _, _ = WordFrequency([]string{a, b}, FreqOptions{Language: "fr", TopN: 2, Stem: true})
+	if !reflect.DeepEqual(words, []WordCount{{"chat", 3}, {"chien", 2}}) {
+		t.Fatalf("stemming did not merge forms: %v", words)
+	}
+}
+
+func TestEnglishContractionsAreStopwords(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "en.txt")
+	os.WriteFile(p, []byte("Don't panic, it's fine. We’re told they've won't stop; the dog's bone hasn't moved.\n"), 0644)
+	words, _, err := WordFrequency([]string{p}, FreqOptions{Language: "en", TopN: 20})
+	if err != nil {
+		t.Fatalf("frequency: %v", err)
+	}
+	got := map[string]bool{}
+	for _, w := range words {
+		got[w.Word] = true
+	}
+	for _, w := range []string{"don't", "it's", "we're", "they've", "won't", "hasn't", "don", "t", "s"} {
+		if got[w] {
+			t.Fatalf("%q counted as a content word: %v", w, words)
+		}
+	}
+	if !got["panic"] || !got["dog's"] {
+		t.Fatalf("content words missing: %v", words)
+	}
+}
+
+func TestStemEnglish(t *testing.T) {
+	cases := map[string]string{"running": "runn", "classes": "class", "glass": "glass", "stories": "stor", "cats": "cat"}
+	for in, want := range cases {
+		if got := stem("en", in); got != want {
+			t.Fatalf("stem(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package fileops
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IncidentOptions regle la detection des pics d'erreurs
+type IncidentOptions struct {
+	Format    string        // format de log, "auto" par defaut
+	Bucket    time.Duration // taille des tranches (minute, heure)
+	MinLevel  string        // niveau compte comme erreur et au-dessus (ERROR par defaut)
+	Filter    FilterOptions // si Expr est renseigne, compte les lignes qui correspondent a la place du niveau
+	Threshold float64       // ecart au taux de reference, en ecarts-types robustes
+	MinHits   int           // erreurs minimum pour signaler une tranche
+	Samples   int           // lignes d'exemple gardees par incident
+}
+
+// RateBucket est une tranche de temps et son taux d'erreurs
+type RateBucket struct {
+	Start   time.Time
+	Events  int
+	Hits    int            // lignes comptees comme erreurs
+	Levels  map[string]int // evenements par niveau
+	Rate    float64        // Hits / Events
+	Score   float64        // ecart au taux de reference
+	Spike   bool
+	samples []string
+}
+
+// Incident regroupe des tranches en pic consecutives
+type Incident struct {
+	Start, End time.Time // End = fin de la derniere tranche
+	Events     int
+	Hits       int
+	Peak       float64 // score le plus haut
+	Samples    []string
+}
+
+// Rate renvoie le taux d'erreurs sur toute la duree de l'incident
+func (in Incident) Rate() float64 {
+	if in.Events == 0 {
+		return 0
+	}
+	return float64(in.Hits) / float64(in.Events)
+}
+
+// IncidentReport est le resultat de DetectIncidents
+type IncidentReport struct {
+	LogScan
+	Path      string
+	Options   IncidentOptions
+	Untimed   int     // lignes reconnues mais sans horodatage
+	Overall   float64 // taux d'erreurs global
+	Baseline  float64 // taux de reference (mediane des tranches)
+	Buckets   []RateBucket
+	Incidents []Incident
+
+	ReportFile string // incidents.txt et error_rate.csv dans outDir
+	RatesFile  string
+}
+
+// DetectIncidents repartit les lignes d'un log en tranches de temps, calcule le
+// taux d'erreurs de chacune et signale celles qui s'ecartent nettement du taux
+// de reference. Les tranches signalees qui se suivent forment un incident.
+func DetectIncidents(path string, o IncidentOptions, outDir string) (*IncidentReport, error) {
+	if o.Format == "" {
+		o.Format = "auto"
+	}
+	if o.Bucket <= 0 {
+		o.Bucket = time.Minute
+	}
+	if o.MinLevel == "" {
+		o.MinLevel = "ERROR"
+	}
+	o.MinLevel = normalizeLevel(o.MinLevel)
+	rank, ok := levelOrder[o.MinLevel]
+	if !ok {
+		return nil, fmt.Errorf("niveau inconnu: %s", o.MinLevel)
+	}
+	var m *Matcher
+	if strings.TrimSpace(o.Filter.Expr) != "" {
+		var err error
+		if m, err = NewMatcher(o.Filter); err != nil {
+			return nil, err
+		}
+	}
+	isHit := func(e LogEntry) bool {
+		if m != nil {
+			return m.Match(e.Line)
+		}
+		r, ok := levelOrder[e.Level]
+		return ok && r <= rank
+	}
+
+	r := &IncidentReport{Path: path, Options: o}
+	buckets := map[int64]*RateBucket{}
+	hits, events := 0, 0
+	scan, err := ParseLog(path, o.Format, func(e LogEntry) {
+		if e.Time.IsZero() {
+			r.Untimed++
+			return
+		}
+		start := e.Time.Truncate(o.Bucket)
+		b := buckets[start.Unix()]
+		if b == nil {
+			b = &RateBucket{Start: start, Levels: map[string]int{}}
+			buckets[start.Unix()] = b
+		}
+		b.Events++
+		b.Levels[e.Level]++
+		events++
+		if isHit(e) {
+			b.Hits++
+			hits++
+			if len(b.samples) < o.Samples {
+				b.samples = append(b.samples, e.Line)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.LogScan = scan
+
+	for _, b := range buckets {
+		b.Rate = float64(b.Hits) / float64(b.Events)
+		r.Buckets = append(r.Buckets, *b)
+	}
+	sort.Slice(r.Buckets, func(i, j int) bool { return r.Buckets[i].Start.Before(r.Buckets[j].Start) })
+	if events > 0 {
+		r.Overall = float64(hits) / float64(events)
+	}
+	r.score()
+	r.group()
+
+	if outDir == "" {
+		return r, nil
+	}
+	if r.RatesFile, err = r.writeRates(filepath.Join(outDir, "error_rate.csv")); err != nil {
+		return nil, err
+	}
+	out, err := createOutput(filepath.Join(outDir, "incidents.txt"))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	r.write(out)
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	r.ReportFile = out.Path
+	return r, nil
+}
+
+// score compare chaque tranche au taux median. L'ecart-type robuste (MAD) est
+// borne par l'ecart-type binomial attendu pour le nombre d'evenements de la
+// tranche : une tranche de 3 lignes dont 1 erreur n'est pas un pic.
+func (r *IncidentReport) score() {
+	if len(r.Buckets) == 0 {
+		return
+	}
+	rates := make([]float64, len(r.Buckets))
+	for i, b := range r.Buckets {
+		rates[i] = b.Rate
+	}
+	r.Baseline = median(rates)
+	for i := range rates {
+		rates[i] = math.Abs(rates[i] - r.Baseline)
+	}
+	mad := 1.4826 * median(rates)
+
+	p := r.Overall
+	for i := range r.Buckets {
+		b := &r.Buckets[i]
+		sigma := max(mad, math.Sqrt(p*(1-p)/float64(b.Events)))
+		if sigma == 0 {
+			continue // aucune erreur, ou que des erreurs
+		}
+		b.Score = (b.Rate - r.Baseline) / sigma
+		b.Spike = b.Score >= r.Options.Threshold && b.Hits >= r.Options.MinHits
+	}
+}
+
+// group fusionne les tranches en pic adjacentes en incidents
+func (r *IncidentReport) group() {
+	var cur *Incident
+	for _, b := range r.Buckets {
+		if !b.Spike {
+			cur = nil
+			continue
+		}
+		if cur == nil || !b.Start.Equal(cur.End) {
+			r.Incidents = append(r.Incidents, Incident{Start: b.Start})
+			cur = &r.Incidents[len(r.Incidents)-1]
+		}
+		cur.End = b.Start.Add(r.Options.Bucket)
+		cur.Events += b.Events
+		cur.Hits += b.Hits
+		cur.Peak = max(cur.Peak, b.Score)
+		for _, s := range b.samples {
+			if len(cur.Samples) < r.Options.Samples {
+				cur.Samples = append(cur.Samples, s)
+			}
+		}
+	}
+}
+
+func median(v []float64) float64 {
+	s := append([]float64(nil), v...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+func (r *IncidentReport) writeRates(path string) (string, error) {
+	out, err := createOutput(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	levels := map[string]int{}
+	for _, b := range r.Buckets {
+		for l, n := range b.Levels {
+			levels[l] += n
+		}
+	}
+	names := sortedLevels(levels)
+	header := []string{"start", "events", "hits", "rate", "score", "spike"}
+	for _, l := range names {
+		if l == "" {
+			l = "none"
+		}
+		header = append(header, strings.ToLower(l))
+	}
+	w := csv.NewWriter(out)
+	w.Write(header)
+	for _, b := range r.Buckets {
+		row := []string{
+			b.Start.Format("2006-01-02 15:04"), strconv.Itoa(b.Events), strconv.Itoa(b.Hits),
+			strconv.FormatFloat(b.Rate, 'f', 4, 64), strconv.FormatFloat(b.Score, 'f', 2, 64), strconv.FormatBool(b.Spike),
+		}
+		for _, l := range names {
+			row = append(row, strconv.Itoa(b.Levels[l]))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return out.Path, out.Close()
+}
+
+// ParseBucket convertit une taille de tranche : minute ou hour
+func ParseBucket(name string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "minute", "m", "":
+		return time.Minute, nil
+	case "hour", "heure", "h":
+		return time.Hour, nil
+	}
+	return 0, fmt.Errorf("tranche inconnue: %s (minute ou hour)", name)
+}
+
+// bucketLabel nomme la taille des tranches
+func bucketLabel(d time.Duration) string {
+	switch d {
+	case time.Minute:
+		return "minute"
+	case time.Hour:
+		return "heure"
+	}
+	return d.String()
+}
+
+// write ecrit le rapport d'incidents (ecran et incidents.txt)
+func (r *IncidentReport) write(w io.Writer) {
+	criterion := "niveau >= " + r.Options.MinLevel
+	if strings.TrimSpace(r.Options.Filter.Expr) != "" {
+		criterion = "lignes \"" + r.Options.Filter.Expr + "\""
+	}
+	fmt.Fprintf(w, "Incidents de %s\n", r.Path)
+	fmt.Fprintf(w, "  Format    : %s (%d/%d lignes reconnues", r.Format, r.Parsed, r.Lines)
+	if r.Untimed > 0 {
+		fmt.Fprintf(w, ", %d sans horodatage", r.Untimed)
+	}
+	fmt.Fprintln(w, ")")
+	fmt.Fprintf(w, "  Tranches  : %d par %s, critere %s\n", len(r.Buckets), bucketLabel(r.Options.Bucket), criterion)
+	fmt.Fprintf(w, "  Taux      : %.1f%% global, %.1f%% de reference (mediane)\n", 100*r.Overall, 100*r.Baseline)
+	fmt.Fprintf(w, "  Seuil     : score >= %.1f et au moins %d erreurs\n", r.Options.Threshold, r.Options.MinHits)
+
+	if len(r.Incidents) == 0 {
+		fmt.Fprintln(w, "\nAucun pic d'erreurs detecte.")
+		return
+	}
+	fmt.Fprintf(w, "\n%d incident(s) :\n", len(r.Incidents))
+	for i, in := range r.Incidents {
+		end := in.End.Format("15:04")
+		if in.End.YearDay() != in.Start.YearDay() || in.End.Year() != in.Start.Year() {
+			end = in.End.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "\n[%d] %s -> %s  erreurs %d/%d (%.1f%%)  score max %.1f\n",
+			i+1, in.Start.Format("2006-01-02 15:04"), end, in.Hits, in.Events, 100*in.Rate(), in.Peak)
+		for _, s := range in.Samples {
+			fmt.Fprintf(w, "    > %s\n", truncate(s, 200))
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// PrintIncidentReport affiche le resultat de DetectIncidents
+func PrintIncidentReport(r *IncidentReport) {
+	r.write(os.Stdout)
+	if r.ReportFile != "" {
+		fmt.Printf("\n  -> rapport dans %s\n", r.ReportFile)
+		fmt.Printf("  -> taux par tranche dans %s\n", r.RatesFile)
+	}
+}
@@ -0,0 +1,92 @@
+package fileops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeRateLog ecrit 20 lignes logfmt par minute ; errs donne le nombre
+// d'erreurs de chaque minute
+func writeRateLog(t *testing.T, errs []int) string {
+	t.Helper()
+	var b strings.Builder
+	for m, n := range errs {
+		for k := 0; k < 20; k++ {
+			level, msg := "info", "ok"
+			if k < n {
+				level, msg = "error", fmt.Sprintf("timeout db%d", k)
+			}
+			fmt.Fprintf(&b, "time=2026-10-19T10:%02d:%02dZ level=%s msg=%q\n", m, k, level, msg)
+		}
+	}
+	p := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(p, []byte(b.String()), 0644)
+	return p
+}
+
+func TestDetectIncidents(t *testing.T) {
+	// bruit de fond a 1 erreur par minute, pic de 10:05 a 10:07
+	errs := []int{1, 0, 1, 1, 0, 12, 15, 1, 0, 1, 1, 2}
+	in := writeRateLog(t, errs)
+	out := t.TempDir()
+	o := IncidentOptions{Threshold: 3, MinHits: 5, Samples: 2}
+
+	r, err := DetectIncidents(in, o, out)
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	if r.Format != "logfmt" || len(r.Buckets) != len(errs) {
+		t.Fatalf("format=%s buckets=%d", r.Format, len(r.Buckets))
+	}
+	if len(r.Incidents) != 1 {
+		t.Fatalf("incidents = %+v", r.Incidents)
+	}
+	inc := r.Incidents[0]
+	start := time.Date(2026, 10, 19, 10, 5, 0, 0, time.UTC)
+	if !inc.Start.Equal(start) || !inc.End.Equal(start.Add(2*time.Minute)) || inc.Hits != 27 || inc.Events != 40 {
+		t.Fatalf("incident = %+v", inc)
+	}
+	if len(inc.Samples) != 2 || !strings.Contains(inc.Samples[0], "timeout db0") {
+		t.Fatalf("samples = %q", inc.Samples)
+	}
+
+	report, err := os.ReadFile(r.ReportFile)
+	if err != nil || !strings.Contains(string(report), "2026-10-19 10:05 -> 10:07  erreurs 27/40") {
+		t.Fatalf("report (%v):\n%s", err, report)
+	}
+	rates, err := os.ReadFile(r.RatesFile)
+	if err != nil || !strings.HasPrefix(string(rates), "start,events,hits,rate,score,spike,error,info\n") {
+		t.Fatalf("rates csv (%v):\n%s", err, rates)
+	}
+
+	// par heure : une seule tranche, rien a comparer
+	o.Bucket = time.Hour
+	if r, err = DetectIncidents(in, o, ""); err != nil || len(r.Buckets) != 1 || len(r.Incidents) != 0 {
+		t.Fatalf("hour buckets: %v %+v", err, r)
+	}
+
+	// compter un motif plutot qu'un niveau : seules les lignes db1 restent
+	o.Bucket, o.Filter = time.Minute, FilterOptions{Expr: "db1", WholeWord: true}
+	if r, err = DetectIncidents(in, o, ""); err != nil || r.Overall != float64(3)/240 {
+		t.Fatalf("pattern: %v overall=%v", err, r.Overall)
+	}
+}
+
+func TestDetectIncidentsSteadyErrors(t *testing.T) {
+	// taux d'erreurs eleve mais stable : pas d'incident
+	in := writeRateLog(t, []int{8, 9, 8, 7, 8, 9, 8, 8})
+	r, err := DetectIncidents(in, IncidentOptions{Threshold: 3, MinHits: 5}, "")
+	if err != nil {
+		t.Fatalf("detect: %v", err)
+	}
+	if len(r.Incidents) != 0 {
+		t.Fatalf("unexpected incidents: %+v", r.Incidents)
+	}
+	if _, err := DetectIncidents(in, IncidentOptions{MinLevel: "loud"}, ""); err == nil {
+		t.Fatalf("unknown level should fail")
+	}
+}
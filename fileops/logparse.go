@@ -0,0 +1,475 @@
+package fileops
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogEntry est une ligne de log decodee
+type LogEntry struct {
+	Time    time.Time         // zero si la ligne n'a pas d'horodatage lisible
+	Level   string            // FATAL, ERROR, WARN, INFO, DEBUG, TRACE ou vide
+	Status  int               // code HTTP (journaux d'acces), 0 sinon
+	Message string            // message libre, sans les champs
+	Fields  map[string]string // autres champs (hote, methode, user...)
+	Line    string            // ligne brute
+}
+
+// logParser decode une ligne ; ok vaut false si la ligne n'est pas dans ce format
+type logParser struct {
+	name  string
+	parse func(line string) (LogEntry, bool)
+}
+
+// formats integres, dans l'ordre d'essai (logfmt en dernier : le plus permissif)
+var builtinLogParsers = []logParser{
+	{"json", parseJSONLog},
+	{"syslog", parseSyslog},
+	{"combined", parseCombined},
+	{"logfmt", parseLogfmt},
+}
+
+// logParsers renvoie les formats de fileops.log_formats puis les formats integres
+func logParsers() ([]logParser, error) {
+	var out []logParser
+	for _, f := range settings.LogFormats {
+		p, err := regexLogParser(f.Name, f.Pattern, f.TimeLayout)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return append(out, builtinLogParsers...), nil
+}
+
+// LogScan resume la lecture d'un log
+type LogScan struct {
+	Format string
+	Lines  int
+	Parsed int // lignes reconnues ; les autres (traces de pile, lignes vides) sont ignorees
+}
+
+// ParseLog lit le log en flux au format donne ("auto" = detection sur les
+// premieres lignes) et appelle fn pour chaque ligne reconnue
+func ParseLog(path, format string, fn func(LogEntry)) (LogScan, error) {
+	p, err := selectLogParser(path, format)
+	if err != nil {
+		return LogScan{}, err
+	}
+	scan := LogScan{Format: p.name}
+	err = scanLines(path, func(line string) bool {
+		scan.Lines++
+		if e, ok := p.parse(line); ok {
+			e.Line = line
+			scan.Parsed++
+			fn(e)
+		}
+		return true
+	})
+	return scan, err
+}
+
+// lignes examinees pour reconnaitre le format
+const logSampleLines = 200
+
+func selectLogParser(path, format string) (logParser, error) {
+	parsers, err := logParsers()
+	if err != nil {
+		return logParser{}, err
+	}
+	if format != "" && format != "auto" {
+		for _, p := range parsers {
+			if p.name == format {
+				return p, nil
+			}
+		}
+		return logParser{}, fmt.Errorf("format de log inconnu: %s", format)
+	}
+
+	hits := make([]int, len(parsers))
+	n := 0
+	err = scanLines(path, func(line string) bool {
+		if strings.TrimSpace(line) == "" {
+			return true
+		}
+		for i, p := range parsers {
+			if _, ok := p.parse(line); ok {
+				hits[i]++
+			}
+		}
+		n++
+		return n < logSampleLines
+	})
+	if err != nil {
+		return logParser{}, err
+	}
+	best := -1
+	for i, h := range hits {
+		if h > 0 && (best < 0 || h > hits[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return logParser{}, fmt.Errorf("%s: format de log non reconnu (json, logfmt, syslog, combined ou fileops.log_formats)", path)
+	}
+	return parsers[best], nil
+}
+
+// --- champs communs ---
+
+var (
+	timeKeys   = []string{"time", "timestamp", "ts", "@timestamp", "date", "datetime"}
+	levelKeys  = []string{"level", "lvl", "severity", "loglevel", "log.level"}
+	msgKeys    = []string{"msg", "message"}
+	statusKeys = []string{"status", "status_code", "statusCode"}
+)
+
+// fromFields remplit le temps, le niveau, le message et le statut a partir des
+// cles usuelles et les retire des champs
+func fromFields(fields map[string]string) LogEntry {
+	e := LogEntry{Fields: fields}
+	if v, ok := takeField(fields, timeKeys); ok {
+		e.Time, _ = parseLogTime(v, "")
+	}
+	if v, ok := takeField(fields, levelKeys); ok {
+		e.Level = normalizeLevel(v)
+	}
+	if v, ok := takeField(fields, msgKeys); ok {
+		e.Message = v
+	}
+	if v, ok := takeField(fields, statusKeys); ok {
+		e.Status, _ = strconv.Atoi(v)
+	}
+	if e.Level == "" {
+		e.Level = levelFromStatus(e.Status)
+	}
+	if e.Level == "" {
+		e.Level = levelFromText(e.Message)
+	}
+	return e
+}
+
+func takeField(fields map[string]string, keys []string) (string, bool) {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			delete(fields, k)
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// normalizeLevel ramene les variantes courantes a FATAL, ERROR, WARN, INFO, DEBUG, TRACE
+func normalizeLevel(s string) string {
+	switch l := strings.ToLower(strings.TrimSpace(s)); l {
+	case "fatal", "panic", "critical", "crit", "emerg", "emergency", "alert", "f":
+		return "FATAL"
+	case "error", "err", "e", "eror", "severe":
+		return "ERROR"
+	case "warn", "warning", "w":
+		return "WARN"
+	case "info", "information", "informational", "notice", "i":
+		return "INFO"
+	case "debug", "dbug", "d":
+		return "DEBUG"
+	case "trace", "t", "verbose", "finest":
+		return "TRACE"
+	case "":
+		return ""
+	default:
+		return strings.ToUpper(l)
+	}
+}
+
+var levelWord = regexp.MustCompile(`(?i)\b(fatal|panic|critical|error|err|warn|warning|info|notice|debug|trace)\b`)
+
+// levelFromText cherche un niveau en tete de message ("ERROR connexion perdue")
+func levelFromText(msg string) string {
+	head := msg
+	if len(head) > 40 {
+		head = head[:40]
+	}
+	if m := levelWord.FindString(head); m != "" {
+		return normalizeLevel(m)
+	}
+	return ""
+}
+
+// levelFromStatus classe une requete HTTP : 5xx = ERROR, 4xx = WARN
+func levelFromStatus(status int) string {
+	switch {
+	case status >= 500:
+		return "ERROR"
+	case status >= 400:
+		return "WARN"
+	case status > 0:
+		return "INFO"
+	}
+	return ""
+}
+
+var logTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+	"02/Jan/2006:15:04:05 -0700",
+	"2006/01/02 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// parseLogTime lit un horodatage : layout s'il est donne, sinon les formats
+// courants ou un temps Unix (secondes, millisecondes)
+func parseLogTime(s, layout string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if layout != "" {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		return t, err == nil
+	}
+	for _, l := range logTimeLayouts {
+		if t, err := time.ParseInLocation(l, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && f > 0 {
+		switch {
+		case f > 1e17: // nanosecondes
+			return time.Unix(0, int64(f)), true
+		case f > 1e11: // millisecondes
+			return time.UnixMilli(int64(f)), true
+		case f > 1e8: // secondes (apres 1973 : un petit nombre n'est pas une date)
+			sec := int64(f)
+			return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// --- JSON lines ---
+
+func parseJSONLog(line string) (LogEntry, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return LogEntry{}, false
+	}
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return LogEntry{}, false
+	}
+	fields := map[string]string{}
+	for k, v := range obj {
+		switch v := v.(type) {
+		case string:
+			fields[k] = v
+		case json.Number:
+			fields[k] = v.String()
+		case bool:
+			fields[k] = strconv.FormatBool(v)
+		case map[string]any:
+			// un niveau d'imbrication : {"http":{"status":200}} -> http.status
+			for sk, sv := range v {
+				switch sv := sv.(type) {
+				case string:
+					fields[k+"."+sk] = sv
+				case json.Number:
+					fields[k+"."+sk] = sv.String()
+				}
+			}
+		}
+	}
+	e := fromFields(fields)
+	if e.Status == 0 {
+		if v, ok := takeField(fields, []string{"http.status", "http.status_code", "response.status"}); ok {
+			e.Status, _ = strconv.Atoi(v)
+			if e.Level == "" {
+				e.Level = levelFromStatus(e.Status)
+			}
+		}
+	}
+	return e, true
+}
+
+// --- logfmt ---
+
+// parseLogfmt lit key=value key2="valeur avec espaces" ; toute la ligne doit
+// etre des paires, deux au moins
+func parseLogfmt(line string) (LogEntry, bool) {
+	fields := map[string]string{}
+	s := strings.TrimSpace(line)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \t\"") {
+			return LogEntry{}, false
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return LogEntry{}, false
+			}
+			v, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				v = s[1:end]
+			}
+			val, s = v, s[end+1:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			val, s = s[:end], s[end:]
+		}
+		fields[key] = val
+		s = strings.TrimLeft(s, " \t")
+	}
+	if len(fields) < 2 {
+		return LogEntry{}, false
+	}
+	return fromFields(fields), true
+}
+
+// --- syslog ---
+
+var (
+	// RFC 5424 : <PRI>1 TIMESTAMP HOST APP PROCID MSGID SD MSG
+	syslog5424 = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\]|\\.)*\])+) ?(.*)$`)
+	// RFC 3164 et /var/log/syslog : [<PRI>]Mmm jj hh:mm:ss HOST APP[PID]: MSG
+	syslog3164 = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d) (\S+) ([^\s:\[]+)(?:\[(\d+)\])?: ?(.*)$`)
+)
+
+// niveaux syslog 0 (emerg) a 7 (debug)
+var syslogLevels = []string{"FATAL", "FATAL", "FATAL", "ERROR", "WARN", "INFO", "INFO", "DEBUG"}
+
+func parseSyslog(line string) (LogEntry, bool) {
+	if m := syslog5424.FindStringSubmatch(line); m != nil {
+		e := LogEntry{Fields: map[string]string{}, Message: strings.TrimPrefix(m[8], "\ufeff")}
+		e.Time, _ = parseLogTime(m[2], "")
+		e.Level = syslogLevel(m[1], e.Message)
+		setField(e.Fields, "host", m[3])
+		setField(e.Fields, "app", m[4])
+		setField(e.Fields, "msgid", m[6])
+		return e, true
+	}
+	if m := syslog3164.FindStringSubmatch(line); m != nil {
+		e := LogEntry{Fields: map[string]string{}, Message: m[6]}
+		e.Time = syslogTime(m[2])
+		e.Level = syslogLevel(m[1], e.Message)
+		setField(e.Fields, "host", m[3])
+		setField(e.Fields, "app", m[4])
+		return e, true
+	}
+	return LogEntry{}, false
+}
+
+// setField ignore la valeur nulle de syslog "-"
+func setField(fields map[string]string, k, v string) {
+	if v != "" && v != "-" {
+		fields[k] = v
+	}
+}
+
+func syslogLevel(pri, msg string) string {
+	if p, err := strconv.Atoi(pri); err == nil {
+		return syslogLevels[p%8]
+	}
+	return levelFromText(msg)
+}
+
+// syslogTime complete l'annee absente en RFC 3164 : annee courante, ou la
+// precedente si la date tombe dans le futur (log de decembre lu en janvier)
+func syslogTime(s string) time.Time {
+	now := time.Now()
+	t, err := time.ParseInLocation("Jan _2 15:04:05 2006", s+" "+strconv.Itoa(now.Year()), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// --- Apache / nginx ---
+
+// combined (et common sans referer ni user-agent) :
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "ref" "ua"
+var combinedLog = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\d+|-)(?: "([^"]*)" "([^"]*)")?`)
+
+func parseCombined(line string) (LogEntry, bool) {
+	m := combinedLog.FindStringSubmatch(line)
+	if m == nil {
+		return LogEntry{}, false
+	}
+	e := LogEntry{Fields: map[string]string{}, Message: m[4]}
+	e.Time, _ = parseLogTime(m[3], "02/Jan/2006:15:04:05 -0700")
+	e.Status, _ = strconv.Atoi(m[5])
+	e.Level = levelFromStatus(e.Status)
+	setField(e.Fields, "client", m[1])
+	setField(e.Fields, "user", m[2])
+	if req := strings.Fields(m[4]); len(req) >= 2 {
+		path := req[1]
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		setField(e.Fields, "method", req[0])
+		setField(e.Fields, "path", path)
+	}
+	setField(e.Fields, "referer", m[7])
+	setField(e.Fields, "agent", m[8])
+	return e, true
+}
+
+// --- formats personnalises ---
+
+func regexLogParser(name, pattern, layout string) (logParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return logParser{}, fmt.Errorf("format de log %s: motif invalide: %w", name, err)
+	}
+	names := re.SubexpNames()
+	return logParser{name: name, parse: func(line string) (LogEntry, bool) {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			return LogEntry{}, false
+		}
+		e := LogEntry{Fields: map[string]string{}}
+		for i, n := range names {
+			switch {
+			case n == "" || m[i] == "":
+			case n == "time":
+				e.Time, _ = parseLogTime(m[i], layout)
+			case n == "level":
+				e.Level = normalizeLevel(m[i])
+			case n == "status":
+				e.Status, _ = strconv.Atoi(m[i])
+			case n == "msg":
+				e.Message = m[i]
+			default:
+				e.Fields[n] = m[i]
+			}
+		}
+		if e.Level == "" {
+			e.Level = levelFromStatus(e.Status)
+		}
+		if e.Level == "" {
+			e.Level = levelFromText(e.Message)
+		}
+		return e, true
+	}}, nil
+}
@@ -0,0 +1,159 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotools/config"
+)
+
+func TestLogParsers(t *testing.T) {
+	cases := []struct {
+		format string
+		line   string
+		level  string
+		status int
+		msg    string
+		fields map[string]string
+	}{
+		{"json", `{"ts":1760868000,"level":"warning","msg":"slow query","db":"main","ms":1200}`,
+			"WARN", 0, "slow query", map[string]string{"db": "main", "ms": "1200"}},
+		{"json", `{"@timestamp":"2026-10-19T10:00:00Z","message":"GET /","http":{"status":502}}`,
+			"ERROR", 502, "GET /", map[string]string{}},
+		{"logfmt", `time=2026-10-19T10:00:00Z level=error msg="connexion perdue" host=db1`,
+			"ERROR", 0, "connexion perdue", map[string]string{"host": "db1"}},
+		{"syslog", `<11>1 2026-10-19T10:00:00Z web1 nginx 42 - - upstream timed out`,
+			"ERROR", 0, "upstream timed out", map[string]string{"host": "web1", "app": "nginx"}},
+		{"syslog", `Oct 19 10:00:00 web1 sshd[123]: Failed password for root`,
+			"", 0, "Failed password for root", map[string]string{"host": "web1", "app": "sshd"}},
+		{"combined", `10.0.0.1 - bob [19/Oct/2026:10:00:00 +0200] "GET /api/users?id=3 HTTP/1.1" 404 512 "-" "curl/8.0"`,
+			"WARN", 404, "GET /api/users?id=3 HTTP/1.1", map[string]string{"client": "10.0.0.1", "user": "bob", "method": "GET", "path": "/api/users", "agent": "curl/8.0"}},
+	}
+	for _, c := range cases {
+		var p logParser
+		for _, bp := range builtinLogParsers {
+			if bp.name == c.format {
+				p = bp
+			}
+		}
+		e, ok := p.parse(c.line)
+		if !ok {
+			t.Fatalf("%s: line not recognised: %s", c.format, c.line)
+		}
+		if e.Level != c.level || e.Status != c.status || e.Message != c.msg || !reflect.DeepEqual(e.Fields, c.fields) {
+			t.Fatalf("%s: got level=%q status=%d msg=%q fields=%v", c.format, e.Level, e.Status, e.Message, e.Fields)
+		}
+		if e.Time.IsZero() {
+			t.Fatalf("%s: no timestamp in %s", c.format, c.line)
+		}
+	}
+
+	e, _ := parseCombined(`10.0.0.1 - - [19/Oct/2026:10:00:00 +0200] "GET / HTTP/1.1" 200 5 "-" "-"`)
+	if want := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC); !e.Time.Equal(want) {
+		t.Fatalf("combined time = %v, want %v", e.Time, want)
+	}
+	if _, ok := parseLogfmt("just some words"); ok {
+		t.Fatalf("plain text must not parse as logfmt")
+	}
+}
+
+func TestParseLogDetectsFormat(t *testing.T) {
+	old := settings
+	t.Cleanup(func() { settings = old })
+	settings.LogFormats = []config.LogFormatConfig{{
+		Name:       "app",
+		Pattern:    `^(?P<time>\d{2}:\d{2}:\d{2}) \[(?P<level>\w+)\] (?P<module>\w+): (?P<msg>.*)$`,
+		TimeLayout: "15:04:05",
+	}}
+
+	tmp := t.TempDir()
+	files := map[string]string{
+		"combined": `1.2.3.4 - - [19/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 200 5 "-" "-"` + "\n",
+		"logfmt":   "level=info msg=ok\nlevel=warn msg=slow\n",
+		"app":      "10:00:00 [ERR] db: timeout\n  at stack frame\n10:00:01 [INFO] api: ok\n",
+	}
+	for want, content := range files {
+		p := filepath.Join(tmp, want+".log")
+		os.WriteFile(p, []byte(content), 0644)
+		var levels []string
+		scan, err := ParseLog(p, "auto", func(e LogEntry) { levels = append(levels, e.Level) })
+		if err != nil {
+			t.Fatalf("%s: %v", want, err)
+		}
+		if scan.Format != want {
+			t.Fatalf("detected %s, want %s", scan.Format, want)
+		}
+		if want == "app" && (scan.Parsed != 2 || scan.Lines != 3 || !reflect.DeepEqual(levels, []string{"ERROR", "INFO"})) {
+			t.Fatalf("app: scan=%+v levels=%v", scan, levels)
+		}
+	}
+
+	p := filepath.Join(tmp, "prose.txt")
+	os.WriteFile(p, []byte("Il etait une fois\n"), 0644)
+	if _, err := ParseLog(p, "auto", func(LogEntry) {}); err == nil {
+		t.Fatalf("prose should not be detected as a log")
+	}
+}
+
+func TestAnalyzeLog(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "app.jsonl")
+	os.WriteFile(in, []byte(`{"time":"2026-10-19T10:00:05Z","level":"info","msg":"ok","route":"/a"}
+{"time":"2026-10-19T10:00:40Z","level":"error","msg":"boom","route":"/b","status":500}
+{"time":"2026-10-19T10:02:00Z","level":"warn","msg":"slow","route":"/a"}
+not json at all
+`), 0644)
+
+	st, err := AnalyzeLog(in, "auto", tmp)
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if st.Format != "json" || st.Parsed != 3 || st.Lines != 4 {
+		t.Fatalf("scan = %+v", st.LogScan)
+	}
+	if st.Levels["ERROR"] != 1 || st.Levels["WARN"] != 1 || st.Levels["INFO"] != 1 || st.Status[500] != 1 {
+		t.Fatalf("levels=%v status=%v", st.Levels, st.Status)
+	}
+	if top := st.TopValues("route", 1); len(top) != 1 || top[0] != (ValueCount{"/a", 2}) {
+		t.Fatalf("top routes = %v", top)
+	}
+	tl := st.Timeline()
+	if len(tl) != 2 || tl[0].Events != 2 || tl[0].Errors != 1 || tl[1].Warnings != 1 {
+		t.Fatalf("timeline = %+v", tl)
+	}
+
+	data, err := os.ReadFile(st.TimelineFile)
+	if err != nil {
+		t.Fatalf("timeline csv: %v", err)
+	}
+	if want := "minute,events,errors,warnings\n2026-10-19 10:00,2,1,0\n2026-10-19 10:02,1,0,1\n"; string(data) != want {
+		t.Fatalf("timeline csv = %q", data)
+	}
+	if data, _ := os.ReadFile(st.FieldsFile); !strings.Contains(string(data), "route,/a,2\n") {
+		t.Fatalf("fields csv = %q", data)
+	}
+}
+
+func TestLogFieldsReportOtherValues(t *testing.T) {
+	v := &valueCounts{counts: map[string]int{}}
+	for i := 0; i < maxFieldValues+2; i++ {
+		v.add(strconv.Itoa(i))
+	}
+	v.add("0")
+	if v.other != 2 || v.counts["0"] != 2 {
+		t.Fatalf("other=%d count[0]=%d", v.other, v.counts["0"])
+	}
+	st := &LogStats{Fields: map[string]*valueCounts{"id": v}}
+	path, err := st.writeFields(filepath.Join(t.TempDir(), "log_fields.csv"), 1)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "field,value,count\nid,0,2\nid,(autres),2\n" {
+		t.Fatalf("fields csv = %q", data)
+	}
+}
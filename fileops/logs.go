@@ -0,0 +1,307 @@
+package fileops
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valeurs distinctes suivies par champ ; au-dela, les nouvelles valeurs sont
+// comptees ensemble sur une ligne "(autres)"
+const maxFieldValues = 10000
+
+const otherValues = "(autres)"
+
+// ValueCount est une valeur de champ et son nombre d'occurrences
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// valueCounts compte les valeurs d'un champ avec une memoire bornee
+type valueCounts struct {
+	counts map[string]int
+	other  int
+}
+
+func (v *valueCounts) add(s string) {
+	if _, ok := v.counts[s]; !ok && len(v.counts) >= maxFieldValues {
+		v.other++
+		return
+	}
+	v.counts[s]++
+}
+
+// top renvoie les n valeurs les plus frequentes (ex aequo par ordre alphabetique)
+func (v *valueCounts) top(n int) []ValueCount {
+	out := make([]ValueCount, 0, len(v.counts))
+	for val, c := range v.counts {
+		out = append(out, ValueCount{val, c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// LogBucket compte les evenements d'une tranche de temps
+type LogBucket struct {
+	Start    time.Time
+	Events   int
+	Errors   int // ERROR et FATAL
+	Warnings int
+}
+
+// LogStats resume un fichier de log
+type LogStats struct {
+	LogScan
+	First, Last time.Time
+	Levels      map[string]int // "" = ligne sans niveau
+	Status      map[int]int
+	Fields      map[string]*valueCounts
+	minutes     map[int64]*LogBucket
+
+	TimelineFile string // CSV ecrits dans outDir
+	FieldsFile   string
+}
+
+// Timeline renvoie les minutes qui ont des evenements, dans l'ordre
+func (st *LogStats) Timeline() []LogBucket {
+	out := make([]LogBucket, 0, len(st.minutes))
+	for _, b := range st.minutes {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// TopValues renvoie les n valeurs les plus frequentes d'un champ
+func (st *LogStats) TopValues(field string, n int) []ValueCount {
+	if vc := st.Fields[field]; vc != nil {
+		return vc.top(n)
+	}
+	return nil
+}
+
+func (st *LogStats) add(e LogEntry) {
+	st.Levels[e.Level]++
+	if e.Status > 0 {
+		st.Status[e.Status]++
+	}
+	for k, v := range e.Fields {
+		vc := st.Fields[k]
+		if vc == nil {
+			vc = &valueCounts{counts: map[string]int{}}
+			st.Fields[k] = vc
+		}
+		vc.add(v)
+	}
+	if e.Time.IsZero() {
+		return
+	}
+	if st.First.IsZero() || e.Time.Before(st.First) {
+		st.First = e.Time
+	}
+	if e.Time.After(st.Last) {
+		st.Last = e.Time
+	}
+	minute := e.Time.Truncate(time.Minute)
+	b := st.minutes[minute.Unix()]
+	if b == nil {
+		b = &LogBucket{Start: minute}
+		st.minutes[minute.Unix()] = b
+	}
+	b.Events++
+	switch e.Level {
+	case "ERROR", "FATAL":
+		b.Errors++
+	case "WARN":
+		b.Warnings++
+	}
+}
+
+// AnalyzeLog decode un fichier de log (format "auto" ou nomme) et compte
+// niveaux, codes HTTP, valeurs des champs et evenements par minute. Avec outDir,
+// la chronologie et les valeurs des champs sont ecrites en CSV.
+func AnalyzeLog(path, format, outDir string) (*LogStats, error) {
+	st := &LogStats{
+		Levels:  map[string]int{},
+		Status:  map[int]int{},
+		Fields:  map[string]*valueCounts{},
+		minutes: map[int64]*LogBucket{},
+	}
+	scan, err := ParseLog(path, format, st.add)
+	if err != nil {
+		return nil, err
+	}
+	st.LogScan = scan
+	if outDir == "" {
+		return st, nil
+	}
+	if st.TimelineFile, err = st.writeTimeline(filepath.Join(outDir, "log_timeline.csv")); err != nil {
+		return nil, err
+	}
+	if st.FieldsFile, err = st.writeFields(filepath.Join(outDir, "log_fields.csv"), settings.TopValues); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (st *LogStats) writeTimeline(path string) (string, error) {
+	out, err := createOutput(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.Write([]string{"minute", "events", "errors", "warnings"})
+	for _, b := range st.Timeline() {
+		w.Write([]string{b.Start.Format("2006-01-02 15:04"), strconv.Itoa(b.Events), strconv.Itoa(b.Errors), strconv.Itoa(b.Warnings)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return out.Path, out.Close()
+}
+
+func (st *LogStats) writeFields(path string, n int) (string, error) {
+	out, err := createOutput(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.Write([]string{"field", "value", "count"})
+	for _, f := range st.fieldNames() {
+		v := st.Fields[f]
+		for _, vc := range v.top(n) {
+			w.Write([]string{f, vc.Value, strconv.Itoa(vc.Count)})
+		}
+		if v.other > 0 {
+			w.Write([]string{f, otherValues, strconv.Itoa(v.other)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return out.Path, out.Close()
+}
+
+func (st *LogStats) fieldNames() []string {
+	names := make([]string, 0, len(st.Fields))
+	for f := range st.Fields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ordre d'affichage des niveaux, les autres suivent par ordre alphabetique
+var levelOrder = map[string]int{"FATAL": 0, "ERROR": 1, "WARN": 2, "INFO": 3, "DEBUG": 4, "TRACE": 5}
+
+func sortedLevels(levels map[string]int) []string {
+	out := make([]string, 0, len(levels))
+	for l := range levels {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		oi, iok := levelOrder[out[i]]
+		oj, jok := levelOrder[out[j]]
+		switch {
+		case iok && jok:
+			return oi < oj
+		case iok != jok:
+			return iok
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// PrintLogStats affiche le resume d'AnalyzeLog ; top = valeurs affichees par champ
+func PrintLogStats(st *LogStats, top int) {
+	fmt.Printf("  Format  : %s (%d/%d lignes reconnues)\n", st.Format, st.Parsed, st.Lines)
+	if !st.First.IsZero() {
+		fmt.Printf("  Periode : %s -> %s\n", st.First.Format("2006-01-02 15:04:05"), st.Last.Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Println("\n--- Niveaux ---")
+	for _, l := range sortedLevels(st.Levels) {
+		name := l
+		if name == "" {
+			name = "(aucun)"
+		}
+		fmt.Printf("  %-8s %8d  %5.1f%%\n", name, st.Levels[l], percent(st.Levels[l], st.Parsed))
+	}
+
+	if len(st.Status) > 0 {
+		fmt.Println("\n--- Codes HTTP ---")
+		codes := make([]int, 0, len(st.Status))
+		for c := range st.Status {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+		for _, c := range codes {
+			fmt.Printf("  %-8d %8d  %5.1f%%\n", c, st.Status[c], percent(st.Status[c], st.Parsed))
+		}
+	}
+
+	if len(st.Fields) > 0 {
+		fmt.Printf("\n--- Champs (top %d) ---\n", top)
+		for _, f := range st.fieldNames() {
+			var parts []string
+			v := st.Fields[f]
+			for _, vc := range v.top(top) {
+				parts = append(parts, fmt.Sprintf("%s (%d)", vc.Value, vc.Count))
+			}
+			if v.other > 0 {
+				parts = append(parts, fmt.Sprintf("%s (%d)", otherValues, v.other))
+			}
+			fmt.Printf("  %-12s %s\n", f, strings.Join(parts, ", "))
+		}
+	}
+
+	timeline := st.Timeline()
+	if len(timeline) > 0 {
+		fmt.Println("\n--- Evenements par minute ---")
+		shown := timeline
+		if len(timeline) > 30 {
+			// trop de minutes pour l'ecran : les plus chargees
+			shown = append([]LogBucket(nil), timeline...)
+			sort.SliceStable(shown, func(i, j int) bool { return shown[i].Events > shown[j].Events })
+			shown = shown[:10]
+			fmt.Printf("  %d minutes, les 10 plus chargees :\n", len(timeline))
+		}
+		peak := 0
+		for _, b := range shown {
+			peak = max(peak, b.Events)
+		}
+		for _, b := range shown {
+			fmt.Printf("  %s %6d %-30s erreurs %d\n", b.Start.Format("2006-01-02 15:04"), b.Events, strings.Repeat("#", b.Events*30/peak), b.Errors)
+		}
+	}
+
+	if st.TimelineFile != "" {
+		fmt.Printf("\n  -> chronologie dans %s\n", st.TimelineFile)
+		fmt.Printf("  -> valeurs des champs dans %s\n", st.FieldsFile)
+	}
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
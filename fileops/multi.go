@@ -2,31 +2,41 @@ package fileops
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"gotools/config"
 )
 
-// BatchAnalyze parcourt tous les .txt d'un dossier et affiche les infos
+var settings = config.DefaultConfig().FileOps
+
+// Configure applique la section fileops de la config
+func Configure(c config.FileOpsConfig) {
+	settings = c
+}
+
+// BatchAnalyze parcourt les fichiers texte d'un dossier et affiche les infos
 func BatchAnalyze(dir string) error {
 	files, err := FindTxtFiles(dir)
 	if err != nil {
 		return err
 	}
 	if len(files) == 0 {
-		fmt.Println("  Aucun fichier .txt dans le dossier", dir)
+		fmt.Printf("  Aucun fichier %s dans le dossier %s\n", strings.Join(settings.Extensions, "/"), dir)
 		return nil
 	}
 	for _, f := range files {
 		fmt.Printf("\n--- %s ---\n", f)
-		if err := FileInfo(f); err != nil {
+		st, err := Analyze(f, AnalyzeOptions{})
+		if err != nil {
 			fmt.Println("  Erreur:", err)
 			continue
 		}
-		if err := WordStats(f); err != nil {
-			fmt.Println("  Erreur:", err)
-		}
+		printInfo(st)
+		printWords(st)
 	}
 	return nil
 }
@@ -37,9 +47,9 @@ func GenerateReport(dir, outDir string) error {
 		return err
 	}
 
-	out, err := os.Create(filepath.Join(outDir, "report.txt"))
+	out, err := createOutput(filepath.Join(outDir, "report.txt"))
 	if err != nil {
-		return fmt.Errorf("impossible de creer report.txt: %w", err)
+		return err
 	}
 	defer out.Close()
 
@@ -49,33 +59,26 @@ func GenerateReport(dir, outDir string) error {
 
 	totalWords, totalLines := 0, 0
 	for _, f := range files {
-		info, err := os.Stat(f)
-		if err != nil {
-			fmt.Fprintf(out, "Fichier : %s\n  Erreur stat: %v\n\n", f, err)
-			continue
-		}
-		lines, err := readLines(f)
+		st, err := Analyze(f, AnalyzeOptions{})
 		if err != nil {
 			fmt.Fprintf(out, "Fichier : %s\n  Erreur lecture: %v\n\n", f, err)
 			continue
 		}
-		words, err := extractWords(f)
-		if err != nil {
-			fmt.Fprintf(out, "Fichier : %s\n  Erreur analyse mots: %v\n\n", f, err)
-			continue
-		}
-		totalLines += len(lines)
-		totalWords += len(words)
+		totalLines += st.Lines
+		totalWords += st.Text.Words
 
 		fmt.Fprintf(out, "Fichier : %s\n", f)
-		fmt.Fprintf(out, "  Taille: %d | Lignes: %d | Mots: %d\n\n", info.Size(), len(lines), len(words))
+		fmt.Fprintf(out, "  Taille: %s | Lignes: %d | Mots: %d\n\n", sizeLabel(st.Size, st.Format), st.Lines, st.Text.Words)
 	}
 
 	fmt.Fprintf(out, "--- TOTAUX ---\n")
 	fmt.Fprintf(out, "Lignes: %d\n", totalLines)
 	fmt.Fprintf(out, "Mots:   %d\n", totalWords)
 
-	fmt.Printf("  -> Rapport ecrit dans %s/report.txt\n", outDir)
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("  -> Rapport ecrit dans %s\n", out.Path)
 	return nil
 }
 
@@ -85,24 +88,36 @@ func GenerateIndex(dir, outDir string) error {
 		return err
 	}
 
-	out, err := os.Create(filepath.Join(outDir, "index.txt"))
+	out, err := createOutput(filepath.Join(outDir, "index.txt"))
 	if err != nil {
-		return fmt.Errorf("impossible de creer index.txt: %w", err)
+		return err
 	}
 	defer out.Close()
 
-	fmt.Fprintf(out, "%-40s %10s  %s\n", "CHEMIN", "TAILLE", "DATE MODIFICATION")
-	fmt.Fprintf(out, "%s\n", strings.Repeat("-", 75))
+	// CONTENU = taille decompressee, egale a TAILLE pour un fichier non compresse
+	fmt.Fprintf(out, "%-40s %10s %10s %-6s  %s\n", "CHEMIN", "TAILLE", "CONTENU", "COMPR.", "DATE MODIFICATION")
+	fmt.Fprintf(out, "%s\n", strings.Repeat("-", 93))
 	for _, f := range files {
 		info, err := os.Stat(f)
 		if err != nil {
 			fmt.Fprintf(out, "%-40s %10s  %s\n", f, "ERR", err.Error())
 			continue
 		}
-		fmt.Fprintf(out, "%-40s %10d  %s\n", f, info.Size(), info.ModTime().Format("2006-01-02 15:04"))
+		content, compression, err := contentSize(f, info)
+		if err != nil {
+			fmt.Fprintf(out, "%-40s %10d  %s\n", f, info.Size(), err.Error())
+			continue
+		}
+		if compression == "" {
+			compression = "-"
+		}
+		fmt.Fprintf(out, "%-40s %10d %10d %-6s  %s\n", f, info.Size(), content, compression, info.ModTime().Format("2006-01-02 15:04"))
 	}
 
-	fmt.Printf("  -> Index ecrit dans %s/index.txt\n", outDir)
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("  -> Index ecrit dans %s\n", out.Path)
 	return nil
 }
 
@@ -112,30 +127,38 @@ func MergeFiles(dir, outDir string) error {
 		return err
 	}
 
-	out, err := os.Create(filepath.Join(outDir, "merged.txt"))
+	out, err := createOutput(filepath.Join(outDir, "merged.txt"))
 	if err != nil {
-		return fmt.Errorf("impossible de creer merged.txt: %w", err)
+		return err
 	}
 	defer out.Close()
 
 	for _, f := range files {
-		data, err := os.ReadFile(f)
+		// contenu decompresse et converti en UTF-8, quel que soit le fichier source
+		r, _, err := openText(f, settings.Encoding)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  Erreur lecture %s: %v\n", f, err)
 			continue
 		}
 		fmt.Fprintf(out, "=== %s ===\n", f)
-		if _, err := out.Write(data); err != nil {
+		_, err = io.Copy(out, r)
+		r.Close()
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "  Erreur ecriture %s: %v\n", f, err)
 			continue
 		}
 		fmt.Fprintln(out)
 	}
 
-	fmt.Printf("  -> Fusion dans %s/merged.txt\n", outDir)
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("  -> Fusion dans %s\n", out.Path)
 	return nil
 }
 
+// FindTxtFiles liste les fichiers du dossier dont l'extension est configuree (.txt
+// par defaut), versions compressees comprises (notes.txt.gz compte comme .txt)
 func FindTxtFiles(dir string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -143,7 +166,7 @@ func FindTxtFiles(dir string) ([]string, error) {
 	}
 	var files []string
 	for _, e := range entries {
-		if !e.IsDir() && strings.HasSuffix(e.Name(), ".txt") {
+		if !e.IsDir() && hasExtension(trimCompressionExt(e.Name()), settings.Extensions) {
 			files = append(files, filepath.Join(dir, e.Name()))
 		}
 	}
@@ -151,5 +174,11 @@ func FindTxtFiles(dir string) ([]string, error) {
 	return files, nil
 }
 
-func ReadLines(path string) ([]string, error)    { return readLines(path) }
-func ExtractWords(path string) ([]string, error) { return extractWords(path) }
+func hasExtension(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(strings.ToLower(name), strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
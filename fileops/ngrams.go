@@ -0,0 +1,251 @@
+package fileops
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gotools/tokenizer"
+)
+
+// NgramOptions regle l'extraction des n-grammes ; Language comme FreqOptions
+type NgramOptions struct {
+	Language string
+	MinCount int // occurrences minimum d'un bigramme ou trigramme
+}
+
+// withDefaults complete les options vides avec la config (langue de la
+// frequence des mots, fileops.ngram_min_count)
+func (o NgramOptions) withDefaults() NgramOptions {
+	if o.Language == "" {
+		o.Language = freqOptions().Language
+	}
+	if o.MinCount < 1 {
+		o.MinCount = max(settings.NgramMinCount, 1)
+	}
+	return o
+}
+
+// Ngram est une suite de mots et son nombre d'occurrences ; PMI n'est
+// renseigne que pour les collocations
+type Ngram struct {
+	Text  string
+	Count int
+	PMI   float64
+}
+
+// NgramResult donne les bigrammes et trigrammes d'un ou plusieurs fichiers, du
+// plus au moins frequent, et les collocations par PMI decroissante
+type NgramResult struct {
+	Files        int
+	Words        int
+	Language     string
+	Bigrams      []Ngram
+	Trigrams     []Ngram
+	Collocations []Ngram
+
+	NgramsFile       string // ngrams.csv et collocations.csv dans outDir
+	CollocationsFile string
+}
+
+// ngramCounter compte mots, bigrammes et trigrammes au fil des lignes. La
+// fenetre ne traverse ni ponctuation, ni nombre, ni ligne vide : "fin. Debut"
+// n'est pas un bigramme.
+type ngramCounter struct {
+	o        NgramOptions
+	words    map[string]int
+	bigrams  map[string]int
+	trigrams map[string]int
+	total    int
+	win      [3]string
+	n        int
+}
+
+func newNgramCounter(o NgramOptions) *ngramCounter {
+	return &ngramCounter{o: o, words: map[string]int{}, bigrams: map[string]int{}, trigrams: map[string]int{}}
+}
+
+func (nc *ngramCounter) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		nc.n = 0
+		return
+	}
+	tokenizer.Each(line, func(t tokenizer.Token) {
+		if t.Kind != tokenizer.Word {
+			nc.n = 0
+			return
+		}
+		w := normalizeWord(t.Text)
+		if w == "" {
+			return
+		}
+		nc.words[w]++
+		nc.total++
+		if nc.n == 3 {
+			nc.win[0], nc.win[1] = nc.win[1], nc.win[2]
+			nc.n = 2
+		}
+		nc.win[nc.n] = w
+		nc.n++
+		if nc.n >= 2 && nc.keep(nc.win[nc.n-2], w) {
+			nc.bigrams[nc.win[nc.n-2]+" "+w]++
+		}
+		if nc.n == 3 && nc.keep(nc.win[0], w) {
+			nc.trigrams[nc.win[0]+" "+nc.win[1]+" "+w]++
+		}
+	})
+}
+
+// keep ecarte les n-grammes qui commencent ou finissent par un mot vide ; ceux
+// du milieu restent ("pomme de terre", "state of the art")
+func (nc *ngramCounter) keep(first, last string) bool {
+	return !isStopword(nc.o.Language, first) && !isStopword(nc.o.Language, last)
+}
+
+// frequent renvoie les n-grammes vus au moins minCount fois, du plus frequent
+// au moins frequent (ex aequo par ordre alphabetique)
+func frequent(counts map[string]int, minCount int) []Ngram {
+	var out []Ngram
+	for g, c := range counts {
+		if c >= minCount {
+			out = append(out, Ngram{Text: g, Count: c})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Text < out[j].Text
+	})
+	return out
+}
+
+// collocations classe les bigrammes frequents par information mutuelle
+// ponctuelle : log2(P(xy) / (P(x) P(y))). Elle mesure a quel point deux mots
+// apparaissent ensemble plus souvent que le hasard ; le seuil MinCount evite
+// que des paires vues une fois dominent le classement.
+func (nc *ngramCounter) collocations(bigrams []Ngram) []Ngram {
+	out := make([]Ngram, 0, len(bigrams))
+	for _, b := range bigrams {
+		x, y, _ := strings.Cut(b.Text, " ")
+		b.PMI = math.Log2(float64(b.Count) * float64(nc.total) / (float64(nc.words[x]) * float64(nc.words[y])))
+		if b.PMI > 0 {
+			out = append(out, b)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].PMI != out[j].PMI {
+			return out[i].PMI > out[j].PMI
+		}
+		return out[i].Count > out[j].Count
+	})
+	return out
+}
+
+// Ngrams compte bigrammes, trigrammes et collocations de fichiers lus en flux
+func Ngrams(paths []string, o NgramOptions) (*NgramResult, error) {
+	o = o.withDefaults()
+	nc := newNgramCounter(o)
+	for _, p := range paths {
+		err := scanLines(p, func(line string) bool {
+			nc.add(line)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		nc.n = 0 // pas de n-gramme a cheval sur deux fichiers
+	}
+	r := &NgramResult{Files: len(paths), Words: nc.total, Language: o.Language}
+	r.Bigrams = frequent(nc.bigrams, o.MinCount)
+	r.Trigrams = frequent(nc.trigrams, o.MinCount)
+	r.Collocations = nc.collocations(r.Bigrams)
+	return r, nil
+}
+
+// NgramReport extrait les n-grammes d'un fichier ou des fichiers d'un dossier
+// et ecrit ngrams.csv (bigrammes et trigrammes) et collocations.csv dans outDir
+func NgramReport(path string, o NgramOptions, outDir string) (*NgramResult, error) {
+	paths := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		if paths, err = FindTxtFiles(path); err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("aucun fichier %s dans %s", strings.Join(settings.Extensions, "/"), path)
+		}
+	}
+	r, err := Ngrams(paths, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{"n", "ngram", "count"}}
+	for _, g := range r.Bigrams {
+		rows = append(rows, []string{"2", g.Text, strconv.Itoa(g.Count)})
+	}
+	for _, g := range r.Trigrams {
+		rows = append(rows, []string{"3", g.Text, strconv.Itoa(g.Count)})
+	}
+	if r.NgramsFile, err = writeCSV(filepath.Join(outDir, "ngrams.csv"), rows); err != nil {
+		return nil, err
+	}
+	rows = [][]string{{"bigram", "count", "pmi"}}
+	for _, g := range r.Collocations {
+		rows = append(rows, []string{g.Text, strconv.Itoa(g.Count), strconv.FormatFloat(g.PMI, 'f', 3, 64)})
+	}
+	if r.CollocationsFile, err = writeCSV(filepath.Join(outDir, "collocations.csv"), rows); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func writeCSV(path string, rows [][]string) (string, error) {
+	out, err := createOutput(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	w := csv.NewWriter(out)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return out.Path, out.Close()
+}
+
+// PrintNgrams affiche le resultat de NgramReport ; top = lignes par classement
+func PrintNgrams(r *NgramResult, top int) {
+	fmt.Printf("  %d fichier(s), %d mots (langue: %s)\n", r.Files, r.Words, r.Language)
+	section := func(title string, grams []Ngram, pmi bool) {
+		fmt.Printf("\n--- %s ---\n", title)
+		if len(grams) == 0 {
+			fmt.Println("  (aucun au-dessus du seuil)")
+			return
+		}
+		if top > 0 && len(grams) > top {
+			grams = grams[:top]
+		}
+		for i, g := range grams {
+			if pmi {
+				fmt.Printf("  %3d. %-30s %6d  PMI %5.2f\n", i+1, g.Text, g.Count, g.PMI)
+			} else {
+				fmt.Printf("  %3d. %-30s %6d\n", i+1, g.Text, g.Count)
+			}
+		}
+	}
+	section("Bigrammes", r.Bigrams, false)
+	section("Trigrammes", r.Trigrams, false)
+	section("Collocations (PMI)", r.Collocations, true)
+	if r.NgramsFile != "" {
+		fmt.Printf("\n  -> n-grammes dans %s\n", r.NgramsFile)
+		fmt.Printf("  -> collocations dans %s\n", r.CollocationsFile)
+	}
+}
@@ -0,0 +1,75 @@
+package fileops
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNgrams(t *testing.T) {
+	tmp := t.TempDir()
+	a := filepath.Join(tmp, "a.txt")
+	b := filepath.Join(tmp, "b.txt")
+	os.WriteFile(a, []byte("La pomme de terre est cuite. La pomme de terre\nest bonne.\n\nTerre cuite, pomme verte.\n"), 0644)
+	os.WriteFile(b, []byte("Le code source du projet. Le code source est libre.\n"), 0644)
+
+	r, err := Ngrams([]string{a, b}, NgramOptions{Language: "fr", MinCount: 2})
+	if err != nil {
+		t.Fatalf("ngrams: %v", err)
+	}
+	got := func(grams []Ngram) map[string]int {
+		m := map[string]int{}
+		for _, g := range grams {
+			m[g.Text] = g.Count
+		}
+		return m
+	}
+	// "la pomme" commence par un mot vide, "cuite pomme" traverse une virgule
+	bi := got(r.Bigrams)
+	if len(bi) != 1 || bi["code source"] != 2 {
+		t.Fatalf("bigrams = %v", bi)
+	}
+	tri := got(r.Trigrams)
+	if len(tri) != 1 || tri["pomme de terre"] != 2 {
+		t.Fatalf("trigrams = %v", tri)
+	}
+
+	// code et source n'apparaissent qu'ensemble : PMI = log2(N / 2)
+	if len(r.Collocations) != 1 || math.Abs(r.Collocations[0].PMI-math.Log2(float64(r.Words)/2)) > 1e-9 {
+		t.Fatalf("collocations = %+v (words %d)", r.Collocations, r.Words)
+	}
+
+	r, err = Ngrams([]string{a}, NgramOptions{Language: "none", MinCount: 2})
+	if err != nil {
+		t.Fatalf("ngrams: %v", err)
+	}
+	if bi := got(r.Bigrams); bi["la pomme"] != 2 || bi["de terre"] != 2 || bi["terre est"] != 2 || len(bi) != 4 {
+		t.Fatalf("bigrams without stopwords = %v", bi)
+	}
+}
+
+func TestNgramReportDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "docs")
+	os.Mkdir(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "un.txt"), []byte("machine learning models\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "deux.txt"), []byte("machine learning rocks\n"), 0644)
+
+	r, err := NgramReport(dir, NgramOptions{Language: "en", MinCount: 2}, tmp)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if r.Files != 2 || len(r.Bigrams) != 1 || r.Bigrams[0] != (Ngram{Text: "machine learning", Count: 2}) {
+		t.Fatalf("result = %+v", r)
+	}
+	data, _ := os.ReadFile(r.NgramsFile)
+	if string(data) != "n,ngram,count\n2,machine learning,2\n" {
+		t.Fatalf("ngrams.csv = %q", data)
+	}
+	data, _ = os.ReadFile(r.CollocationsFile)
+	if !strings.HasPrefix(string(data), "bigram,count,pmi\nmachine learning,2,1.585\n") {
+		t.Fatalf("collocations.csv = %q", data)
+	}
+}
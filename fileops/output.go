@@ -0,0 +1,280 @@
+package fileops
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run regroupe les fichiers produits par une action (analyse d'un fichier ou
+// d'un dossier) : meme horodatage dans les noms, dossier propre si
+// fileops.run_dir est renseigne, et une ligne de manifeste a la fin.
+type Run struct {
+	Dir     string // dossier ou ecrire les sorties de l'execution
+	Input   string
+	Started time.Time
+
+	root  string
+	base  string
+	mu    sync.Mutex
+	files []OutputEntry
+}
+
+// OutputEntry est un fichier produit, tel qu'inscrit dans le manifeste
+type OutputEntry struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+var (
+	runMu   sync.Mutex
+	current *Run
+)
+
+// BeginRun demarre une execution pour input (fichier ou dossier) ; les sorties
+// creees jusqu'a End en font partie
+func BeginRun(outDir, input string) (*Run, error) {
+	r := &Run{Dir: outDir, Input: input, Started: time.Now(), root: outDir, base: baseName(input)}
+	if settings.RunDir != "" {
+		dir, err := reserveDir(filepath.Join(outDir, expandName(settings.RunDir, r, "run", "")))
+		if err != nil {
+			return nil, err
+		}
+		r.Dir = dir
+	}
+	runMu.Lock()
+	current = r
+	runMu.Unlock()
+	return r, nil
+}
+
+// End termine l'execution et ajoute la liste des fichiers produits a
+// outDir/manifest.jsonl (fileops.manifest)
+func (r *Run) End() error {
+	runMu.Lock()
+	if current == r {
+		current = nil
+	}
+	runMu.Unlock()
+
+	r.mu.Lock()
+	files := r.files
+	r.mu.Unlock()
+	if !settings.Manifest || len(files) == 0 {
+		return nil
+	}
+
+	line, err := json.Marshal(struct {
+		Started  time.Time     `json:"started"`
+		Finished time.Time     `json:"finished"`
+		Input    string        `json:"input"`
+		Dir      string        `json:"dir"`
+		Files    []OutputEntry `json:"files"`
+	}{r.Started, time.Now(), r.Input, r.Dir, files})
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(r.root, "manifest.jsonl")
+	f, err := os.OpenFile(dest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("impossible d'ouvrir %s: %w", dest, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("ecriture %s: %w", dest, err)
+	}
+	return f.Close()
+}
+
+func (r *Run) add(e OutputEntry) {
+	r.mu.Lock()
+	r.files = append(r.files, e)
+	r.mu.Unlock()
+}
+
+func activeRun() *Run {
+	runMu.Lock()
+	defer runMu.Unlock()
+	return current
+}
+
+// baseName renvoie le nom de l'entree sans dossier ni extensions : "data/app.log.gz" -> "app"
+func baseName(input string) string {
+	name := trimCompressionExt(filepath.Base(filepath.Clean(input)))
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// expandName remplit un modele de nom : {base} (entree sans extension), {op}
+// (head, filtered, report...), {ext}, {timestamp} et {date} (debut de l'execution)
+func expandName(tmpl string, r *Run, op, ext string) string {
+	base, started := "", time.Now()
+	if r != nil {
+		base, started = r.base, r.Started
+	}
+	name := strings.NewReplacer(
+		"{base}", base,
+		"{op}", op,
+		"{ext}", ext,
+		"{timestamp}", started.Format("20060102-150405"),
+		"{date}", started.Format("2006-01-02"),
+	).Replace(tmpl)
+	// {base} vide (pas d'execution en cours) : pas de separateur orphelin
+	stem := strings.TrimSuffix(name, ext)
+	return strings.Trim(stem, "_-. ") + name[len(stem):]
+}
+
+// outputFile est un fichier genere, ecrit via un tampon et compresse si demande
+type outputFile struct {
+	*bufio.Writer
+	Path string
+	f    *os.File
+	gz   *gzip.Writer
+	op   string
+	run  *Run
+}
+
+// createOutput cree un fichier de sortie ; path donne le dossier et le nom
+// historique ("out/head.txt") que fileops.output_name peut remplacer. Un fichier
+// existant est traite selon fileops.overwrite.
+func createOutput(path string) (*outputFile, error) {
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	op := strings.TrimSuffix(name, ext)
+	r := activeRun()
+
+	name = expandName(settings.OutputName, r, op, ext)
+	if settings.CompressOutput == "gzip" {
+		name += ".gz"
+	}
+	path = filepath.Join(dir, name)
+	if d := filepath.Dir(path); d != "." {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, fmt.Errorf("impossible de creer %s: %w", d, err)
+		}
+	}
+	f, path, err := createFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &outputFile{Path: path, f: f, op: op, run: r}
+	var w io.Writer = f
+	if settings.CompressOutput == "gzip" {
+		o.gz = gzip.NewWriter(f)
+		w = o.gz
+	}
+	o.Writer = bufio.NewWriter(w)
+	return o, nil
+}
+
+// createFile applique fileops.overwrite : overwrite remplace le fichier, suffix
+// ajoute _1, _2... au nom, fail refuse d'ecraser
+func createFile(path string) (*os.File, string, error) {
+	const excl = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	switch settings.Overwrite {
+	case "fail":
+		f, err := os.OpenFile(path, excl, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			return nil, "", fmt.Errorf("%s existe deja (fileops.overwrite = fail)", path)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("impossible de creer %s: %w", path, err)
+		}
+		return f, path, nil
+	case "suffix":
+		for i := 0; ; i++ {
+			p := withSuffix(path, i)
+			f, err := os.OpenFile(p, excl, 0644)
+			if errors.Is(err, fs.ErrExist) {
+				continue
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("impossible de creer %s: %w", p, err)
+			}
+			return f, p, nil
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("impossible de creer %s: %w", path, err)
+	}
+	return f, path, nil
+}
+
+// reserveDir cree le dossier d'une execution selon fileops.overwrite (un dossier
+// existant est reutilise avec overwrite)
+func reserveDir(dir string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("impossible de creer %s: %w", filepath.Dir(dir), err)
+	}
+	for i := 0; ; i++ {
+		d := withSuffix(dir, i)
+		err := os.Mkdir(d, 0755)
+		switch {
+		case err == nil:
+			return d, nil
+		case errors.Is(err, fs.ErrExist) && settings.Overwrite == "suffix":
+			continue
+		case errors.Is(err, fs.ErrExist) && settings.Overwrite == "fail":
+			return "", fmt.Errorf("%s existe deja (fileops.overwrite = fail)", d)
+		case errors.Is(err, fs.ErrExist):
+			return d, nil
+		default:
+			return "", fmt.Errorf("impossible de creer %s: %w", d, err)
+		}
+	}
+}
+
+// withSuffix insere _i avant l'extension : "out/head.txt.gz", 2 -> "out/head_2.txt.gz"
+func withSuffix(path string, i int) string {
+	if i == 0 {
+		return path
+	}
+	dir, name := filepath.Split(path)
+	gz := ""
+	if strings.HasSuffix(name, ".gz") {
+		name, gz = strings.TrimSuffix(name, ".gz"), ".gz"
+	}
+	ext := filepath.Ext(name)
+	return dir + strings.TrimSuffix(name, ext) + "_" + strconv.Itoa(i) + ext + gz
+}
+
+// Close vide les tampons, ferme le fichier et l'inscrit dans l'execution en
+// cours ; un second appel ne fait rien
+func (o *outputFile) Close() error {
+	if o.f == nil {
+		return nil
+	}
+	err := o.Flush()
+	if o.gz != nil {
+		if cerr := o.gz.Close(); err == nil {
+			err = cerr
+		}
+	}
+	var size int64
+	if info, serr := o.f.Stat(); serr == nil {
+		size = info.Size()
+	}
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	o.f = nil
+	if err == nil && o.run != nil {
+		o.run.add(OutputEntry{Op: o.op, Path: o.Path, Bytes: size})
+	}
+	return err
+}
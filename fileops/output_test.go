@@ -0,0 +1,119 @@
+package fileops
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withSettings(t *testing.T, fn func()) {
+	t.Helper()
+	old := settings
+	t.Cleanup(func() { settings = old })
+	fn()
+}
+
+func TestRunTemplateAndManifest(t *testing.T) {
+	withSettings(t, func() {
+		settings.OutputName = "{base}_{op}_{timestamp}{ext}"
+		settings.RunDir = "{base}"
+		settings.Overwrite = "suffix"
+	})
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "app.log.gz")
+	os.WriteFile(in, gzipBytes(t, "error one\nok\n"), 0644)
+	out := filepath.Join(tmp, "out")
+
+	run, err := BeginRun(out, in)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if run.Dir != filepath.Join(out, "app") {
+		t.Fatalf("run dir = %s", run.Dir)
+	}
+	st, err := Analyze(in, AnalyzeOptions{Filter: FilterOptions{Expr: "error"}, N: 1, OutDir: run.Dir})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	stamp := run.Started.Format("20060102-150405")
+	if want := filepath.Join(run.Dir, "app_head_"+stamp+".txt"); st.HeadFile != want {
+		t.Fatalf("head file = %s, want %s", st.HeadFile, want)
+	}
+	if err := run.End(); err != nil {
+		t.Fatalf("end: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "manifest.jsonl"))
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	var m struct {
+		Input string
+		Dir   string
+		Files []OutputEntry
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("manifest json: %v (%s)", err, data)
+	}
+	ops := map[string]bool{}
+	for _, f := range m.Files {
+		ops[f.Op] = true
+		if _, err := os.Stat(f.Path); err != nil {
+			t.Fatalf("manifest lists missing file: %v", err)
+		}
+	}
+	for _, op := range []string{"filtered", "filtered_not", "head", "tail"} {
+		if !ops[op] {
+			t.Fatalf("manifest lacks %s: %+v", op, m.Files)
+		}
+	}
+
+	// meme seconde, meme base : le second dossier recoit un suffixe
+	again, err := BeginRun(out, in)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer again.End()
+	if again.Dir != filepath.Join(out, "app_1") {
+		t.Fatalf("second run dir = %s", again.Dir)
+	}
+}
+
+func TestOverwritePolicies(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "in.txt")
+	os.WriteFile(in, []byte("one\n"), 0644)
+
+	withSettings(t, func() { settings.Overwrite = "suffix" })
+	for i := 0; i < 3; i++ {
+		if err := Head(in, 1, tmp); err != nil {
+			t.Fatalf("head: %v", err)
+		}
+	}
+	for _, name := range []string{"head.txt", "head_1.txt", "head_2.txt"} {
+		if _, err := os.Stat(filepath.Join(tmp, name)); err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+	}
+
+	settings.Overwrite = "fail"
+	err := Head(in, 1, tmp)
+	if err == nil || !strings.Contains(err.Error(), "existe deja") {
+		t.Fatalf("expected overwrite error, got %v", err)
+	}
+}
+
+func TestWithSuffix(t *testing.T) {
+	cases := map[string]string{
+		"out/head.txt":    "out/head_2.txt",
+		"out/head.txt.gz": "out/head_2.txt.gz",
+		"out/run":         "out/run_2",
+	}
+	for in, want := range cases {
+		if got := withSuffix(filepath.FromSlash(in), 2); got != filepath.FromSlash(want) {
+			t.Fatalf("withSuffix(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package fileops
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReportEntry est une ligne "Fichier" de report.txt
+type ReportEntry struct {
+	Size  int64
+	Lines int
+	Words int
+	Err   string // "Erreur lecture" du rapport
+}
+
+// ReportDelta compare un fichier present dans l'un ou l'autre rapport
+type ReportDelta struct {
+	File     string // chemin relatif au dossier analyse
+	Status   string // ajoute, supprime, modifie
+	Old, New ReportEntry
+}
+
+// ReportDiff compare deux rapports produits par GenerateReport
+type ReportDiff struct {
+	A, B      string
+	Rows      []ReportDelta // fichiers ajoutes, supprimes ou modifies
+	Unchanged int
+	Old, New  ReportEntry // totaux des fichiers lus
+	File      string      // report_diff.txt dans outDir
+	filesA    int
+	filesB    int
+}
+
+var reportStats = regexp.MustCompile(`^\s+Taille: (\d+) octets.*\| Lignes: (\d+) \| Mots: (\d+)$`)
+
+// parseReport relit report.txt (compresse ou non) : fichiers indexes par leur
+// chemin relatif au dossier du rapport
+func parseReport(path string) (map[string]ReportEntry, error) {
+	files := map[string]ReportEntry{}
+	dir, cur, header := "", "", false
+	err := scanLines(path, func(line string) bool {
+		switch {
+		case line == "=== RAPPORT GLOBAL ===":
+			header = true
+		case strings.HasPrefix(line, "Dossier"):
+			if _, v, ok := strings.Cut(line, ":"); ok {
+				dir = strings.TrimSpace(v)
+			}
+		case strings.HasPrefix(line, "Fichier : "):
+			cur = strings.TrimPrefix(line, "Fichier : ")
+			if rel, err := filepath.Rel(dir, cur); err == nil && dir != "" {
+				cur = rel
+			}
+		case cur != "" && strings.HasPrefix(line, "  Erreur lecture: "):
+			files[cur] = ReportEntry{Err: strings.TrimPrefix(line, "  Erreur lecture: ")}
+			cur = ""
+		case cur != "":
+			if m := reportStats.FindStringSubmatch(line); m != nil {
+				size, _ := strconv.ParseInt(m[1], 10, 64)
+				lines, _ := strconv.Atoi(m[2])
+				words, _ := strconv.Atoi(m[3])
+				files[cur] = ReportEntry{Size: size, Lines: lines, Words: words}
+				cur = ""
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("%s n'est pas un rapport (report.txt)", path)
+	}
+	return files, nil
+}
+
+// DiffReports compare deux rapports : fichiers ajoutes, supprimes et ceux dont
+// la taille, les lignes ou les mots ont change. Le resultat est ecrit dans
+// outDir/report_diff.txt.
+func DiffReports(a, b, outDir string) (*ReportDiff, error) {
+	old, err := parseReport(a)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := parseReport(b)
+	if err != nil {
+		return nil, err
+	}
+	d := &ReportDiff{A: a, B: b, filesA: len(old), filesB: len(cur)}
+
+	names := make([]string, 0, len(old)+len(cur))
+	for f := range old {
+		names = append(names, f)
+	}
+	for f := range cur {
+		if _, ok := old[f]; !ok {
+			names = append(names, f)
+		}
+	}
+	sort.Strings(names)
+	for _, f := range names {
+		o, inOld := old[f]
+		n, inNew := cur[f]
+		d.Old.add(o)
+		d.New.add(n)
+		switch {
+		case !inOld:
+			d.Rows = append(d.Rows, ReportDelta{f, "ajoute", o, n})
+		case !inNew:
+			d.Rows = append(d.Rows, ReportDelta{f, "supprime", o, n})
+		case o != n:
+			d.Rows = append(d.Rows, ReportDelta{f, "modifie", o, n})
+		default:
+			d.Unchanged++
+		}
+	}
+
+	if outDir == "" {
+		return d, nil
+	}
+	out, err := createOutput(filepath.Join(outDir, "report_diff.txt"))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	d.write(out)
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	d.File = out.Path
+	return d, nil
+}
+
+func (e *ReportEntry) add(o ReportEntry) {
+	e.Size += o.Size
+	e.Lines += o.Lines
+	e.Words += o.Words
+}
+
+// change affiche "avant -> apres (+delta)" ou la valeur seule si elle n'a pas bouge
+func change(old, cur int64) string {
+	if old == cur {
+		return strconv.FormatInt(cur, 10)
+	}
+	return fmt.Sprintf("%d -> %d (%+d)", old, cur, cur-old)
+}
+
+func (d *ReportDiff) write(w io.Writer) {
+	fmt.Fprintf(w, "Avant : %s (%d fichiers)\n", d.A, d.filesA)
+	fmt.Fprintf(w, "Apres : %s (%d fichiers)\n\n", d.B, d.filesB)
+	if len(d.Rows) == 0 {
+		fmt.Fprintf(w, "Aucun changement (%d fichiers identiques)\n", d.Unchanged)
+		return
+	}
+	width := len("FICHIER")
+	for _, r := range d.Rows {
+		width = max(width, len(r.File))
+	}
+	fmt.Fprintf(w, "%-*s  %-8s  %-24s  %-20s  %s\n", width, "FICHIER", "ETAT", "TAILLE", "LIGNES", "MOTS")
+	for _, r := range d.Rows {
+		if r.Old.Err != "" || r.New.Err != "" {
+			fmt.Fprintf(w, "%-*s  %-8s  erreur de lecture\n", width, r.File, r.Status)
+			continue
+		}
+		fmt.Fprintf(w, "%-*s  %-8s  %-24s  %-20s  %s\n", width, r.File, r.Status,
+			change(r.Old.Size, r.New.Size), change(int64(r.Old.Lines), int64(r.New.Lines)), change(int64(r.Old.Words), int64(r.New.Words)))
+	}
+	fmt.Fprintf(w, "\n%d changement(s), %d identique(s)\n", len(d.Rows), d.Unchanged)
+	fmt.Fprintf(w, "Total : taille %s, lignes %s, mots %s\n",
+		change(d.Old.Size, d.New.Size), change(int64(d.Old.Lines), int64(d.New.Lines)), change(int64(d.Old.Words), int64(d.New.Words)))
+}
+
+// PrintReportDiff affiche le resultat de DiffReports
+func PrintReportDiff(d *ReportDiff) {
+	d.write(os.Stdout)
+	if d.File != "" {
+		fmt.Printf("\n  -> comparaison ecrite dans %s\n", d.File)
+	}
+}
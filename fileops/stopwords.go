@@ -0,0 +1,56 @@
+package fileops
+
+import "strings"
+
+// mots vides : articles, pronoms, prepositions, auxiliaires courants
+var stopwords = map[string]map[string]bool{
+	"fr": wordSet(`
+		a ai aie aient aies ait alors as au aucun aura aurai auraient aurais aurait
+		aussi autre aux avaient avais avait avant avec avez aviez avions avoir avons
+		ayant bien c ca car ce ceci cela celle celles celui ces cet cette ceux chaque
+		ci comme comment d dans de des deja depuis donc dont du elle elles en encore
+		entre es est et etaient etais etait etant ete etes etre eu eux fait faire
+		fois furent fut ici il ils j je jusqu l la le les leur leurs lui m ma mais
+		me meme memes mes moi moins mon n ne ni nos notre nous on ont ou par parce
+		pas peu peut plus pour pourquoi qu quand que quel quelle quelles quels qui
+		quoi s sa sans se sera serait ses si sien sienne son sont sous soyez suis
+		sur t ta tandis te tes toi ton tous tout toute toutes tres tu un une vers
+		voici voila vos votre vous y à où été être déjà même mêmes très ça étaient
+		étais était étant étiez étions`),
+	"en": wordSet(`
+		a about above after again against all am an and any are as at be because
+		been before being below between both but by can could did do does doing down
+		during each few for from further had has have having he her here hers herself
+		him himself his how i if in into is it its itself just me more most my myself
+		no nor not now of off on once only or other our ours ourselves out over own
+		same she should so some such than that the their theirs them themselves then
+		there these they this those through to too under until up very was we were
+		what when where which while who whom why will with would you your yours
+		yourself yourselves s t d ll m re ve don doesn didn isn wasn aren weren won
+		wouldn shouldn couldn hasn haven hadn mustn needn shan mightn ain can't
+		cannot`),
+}
+
+func wordSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(list) {
+		set[w] = true
+	}
+	return set
+}
+
+// isStopword indique un mot vide de la langue (fr, en) ; autre langue = aucun.
+// Une contraction anglaise ("don't", "it's", "we're") en est un si ses deux
+// parties en sont ; les fragments seuls ("don", "t", "re") sont dans la liste.
+func isStopword(lang, word string) bool {
+	set := stopwords[lang]
+	if set[word] {
+		return true
+	}
+	if lang == "en" {
+		if head, tail, ok := strings.Cut(word, "'"); ok && head != "" {
+			return set[head] && set[tail]
+		}
+	}
+	return false
+}
@@ -0,0 +1,382 @@
+package fileops
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gotools/tokenizer"
+)
+
+// taille maximale d'une ligne, au-dela le fichier n'est pas un texte ligne a ligne
+const maxLineSize = 64 * 1024 * 1024
+
+// scanLines lit le fichier ligne par ligne sans le charger en memoire ;
+// fn renvoie false pour arreter la lecture
+func scanLines(path string, fn func(line string) bool) error {
+	_, err := scanText(path, fn)
+	return err
+}
+
+// scanText lit le fichier converti en UTF-8 (encodage detecte ou fileops.encoding),
+// lignes sans leur \r\n ou \n, et compte les fins de ligne rencontrees
+func scanText(path string, fn func(line string) bool) (TextFormat, error) {
+	r, tf, err := openText(path, settings.Encoding)
+	if err != nil {
+		return tf, err
+	}
+	defer r.Close()
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLineSize)
+	sc.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		adv, tok, err := bufio.ScanLines(data, atEOF)
+		if tok != nil && adv > 0 && data[adv-1] == '\n' {
+			if adv >= 2 && data[adv-2] == '\r' {
+				tf.CRLF++
+			} else {
+				tf.LF++
+			}
+		}
+		return adv, tok, err
+	})
+	for sc.Scan() {
+		if !fn(sc.Text()) {
+			return tf, nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return tf, fmt.Errorf("lecture %s: %w", path, err)
+	}
+	tf.Size = r.Size()
+	return tf, nil
+}
+
+// Stats regroupe ce que l'analyse d'un fichier calcule en une seule lecture
+type Stats struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Format  TextFormat // encodage et fins de ligne
+	Lines   int
+	Text    tokenizer.Stats // mots, caracteres par classe, longueurs de lignes
+	Keyword string          // expression du filtre
+	Matches int             // lignes retenues par le filtre
+	Head    []string
+	Tail    []string
+
+	HeadFile, TailFile string        // fichiers ecrits dans OutDir
+	Filtered           *FilterResult // rempli quand le filtre ecrit dans OutDir
+
+	TopWords []WordCount
+	Counted  int // mots comptes pour la frequence (hors mots vides)
+}
+
+// AnalyzeOptions : Filter.Expr vide = pas de comptage ni de filtrage, N lignes
+// pour head/tail, OutDir vide = rien n'est ecrit (filtered*.txt, head.txt, tail.txt)
+type AnalyzeOptions struct {
+	Filter   FilterOptions
+	N        int
+	OutDir   string
+	TopWords int // mots les plus frequents (0 = pas de frequence), langue selon la config
+
+	// Vocabulary compte aussi les mots distincts (diversite lexicale), au prix
+	// d'une memoire proportionnelle au vocabulaire (plafonnee par tokenizer.Metrics)
+	Vocabulary bool
+}
+
+// Analyze calcule toutes les statistiques d'un fichier en un seul passage, avec
+// une memoire bornee (une ligne + les N lignes de head/tail) : les lignes filtrees
+// sont ecrites au fil de la lecture.
+func Analyze(path string, opts AnalyzeOptions) (*Stats, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("fichier introuvable: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s est un dossier, pas un fichier", path)
+	}
+	st := &Stats{Path: path, Size: info.Size(), ModTime: info.ModTime(), Keyword: opts.Filter.Expr}
+	st.Text.Prose.Vocabulary = opts.Vocabulary
+
+	var m *Matcher
+	var lf *lineFilter
+	if opts.Filter.Expr != "" {
+		if m, err = NewMatcher(opts.Filter); err != nil {
+			return nil, err
+		}
+		if opts.OutDir != "" {
+			if lf, err = newLineFilter(m, opts.Filter, opts.OutDir); err != nil {
+				return nil, err
+			}
+			defer lf.close()
+		}
+	}
+
+	var freq *wordCounter
+	if opts.TopWords > 0 {
+		freq = newWordCounter(freqOptions())
+	}
+
+	tail := newRing(opts.N)
+	st.Format, err = scanText(path, func(line string) bool {
+		st.Lines++
+		st.Text.AddLine(line)
+		switch {
+		case lf != nil:
+			if lf.feed(line) {
+				st.Matches++
+			}
+		case m != nil:
+			if m.Match(line) {
+				st.Matches++
+			}
+		}
+		if len(st.Head) < opts.N {
+			st.Head = append(st.Head, line)
+		}
+		tail.push(line)
+		if freq != nil {
+			freq.add(line)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	st.Tail = tail.lines()
+	if freq != nil {
+		st.TopWords, st.Counted = freq.top(opts.TopWords), freq.total
+	}
+
+	if opts.OutDir == "" {
+		return st, nil
+	}
+	if lf != nil {
+		if err := lf.close(); err != nil {
+			return nil, err
+		}
+		st.Filtered = &lf.res
+	}
+	if st.HeadFile, err = writeLines(filepath.Join(opts.OutDir, "head.txt"), st.Head); err != nil {
+		return nil, err
+	}
+	if st.TailFile, err = writeLines(filepath.Join(opts.OutDir, "tail.txt"), st.Tail); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// PrintStats affiche le resultat d'Analyze avec les memes sections que le menu A
+func PrintStats(st *Stats, opts AnalyzeOptions) {
+	fmt.Println("\n--- Infos fichier ---")
+	printInfo(st)
+	fmt.Println("\n--- Stats mots ---")
+	printWords(st)
+	fmt.Println("\n--- Phrases et lisibilite ---")
+	printProse(st)
+
+	fmt.Println("\n--- Caracteres ---")
+	printChars(st)
+
+	if len(st.TopWords) > 0 {
+		fmt.Println("\n--- Mots frequents ---")
+		printTopWords(st.TopWords, st.Counted)
+	}
+
+	if st.Keyword != "" {
+		fmt.Println("\n--- Comptage ---")
+		fmt.Printf("  Lignes contenant \"%s\" : %d\n", st.Keyword, st.Matches)
+		if st.Filtered != nil {
+			fmt.Println("\n--- Filtrage ---")
+			PrintFilterResult(st.Filtered)
+		}
+	}
+	if opts.OutDir != "" {
+		fmt.Println("\n--- Head ---")
+		fmt.Printf("  -> %d premieres lignes ecrites dans %s\n", len(st.Head), st.HeadFile)
+		fmt.Println("\n--- Tail ---")
+		fmt.Printf("  -> %d dernieres lignes ecrites dans %s\n", len(st.Tail), st.TailFile)
+	}
+}
+
+func printInfo(st *Stats) {
+	fmt.Printf("  Fichier    : %s\n", st.Path)
+	fmt.Printf("  Taille     : %s\n", sizeLabel(st.Size, st.Format))
+	fmt.Printf("  Modifie    : %s\n", st.ModTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Nb lignes  : %d\n", st.Lines)
+	fmt.Printf("  Encodage   : %s\n", st.Format)
+	fmt.Printf("  Fins ligne : %s\n", st.Format.LineEndings())
+}
+
+func printWords(st *Stats) {
+	fmt.Printf("  Mots (hors numeriques) : %d\n", st.Text.Words)
+	fmt.Printf("  Longueur moyenne       : %.1f caracteres\n", st.Text.AvgWordLen())
+}
+
+func printProse(st *Stats) {
+	p := &st.Text.Prose
+	fmt.Printf("  Phrases                : %d (%.1f mots en moyenne)\n", p.Sentences(), p.AvgSentenceLen())
+	fmt.Printf("  Paragraphes            : %d\n", p.Paragraphs)
+	if p.Vocabulary {
+		printVocabulary(p)
+	}
+	lang := freqOptions().Language
+	if score, formula := p.Readability(lang); formula != "" {
+		fmt.Printf("  Lisibilite             : %.1f (%s, %s)\n", score, formula, tokenizer.ReadabilityLabel(score))
+	}
+}
+
+func printVocabulary(p *tokenizer.Metrics) {
+	if p.TypesCapped() {
+		fmt.Printf("  Diversite lexicale     : >= %.3f (plus de %d mots distincts, approximative)\n", p.TTR(), p.Types())
+		return
+	}
+	fmt.Printf("  Diversite lexicale     : %.3f (%d mots distincts)\n", p.TTR(), p.Types())
+}
+
+func printChars(st *Stats) {
+	c := st.Text.Chars
+	fmt.Printf("  Caracteres : %d (lettres %d dont %d majuscules, chiffres %d, espaces %d, ponctuation %d, symboles %d, autres %d)\n",
+		c.Runes, c.Letters, c.Upper, c.Digits, c.Spaces, c.Punct, c.Symbols, c.Other)
+	fmt.Printf("  Nombres    : %d | Ligne la plus longue : %d caracteres\n", st.Text.Numbers, st.Text.LineHist.Max)
+	fmt.Println("  Longueur des lignes :")
+	st.Text.LineHist.Write(os.Stdout)
+}
+
+// ring garde les n dernieres lignes vues
+type ring struct {
+	buf   []string
+	next  int
+	count int
+}
+
+func newRing(n int) *ring {
+	if n < 0 {
+		n = 0
+	}
+	return &ring{buf: make([]string, n)}
+}
+
+func (r *ring) push(line string) {
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = line
+	r.next = (r.next + 1) % len(r.buf)
+	if r.count < len(r.buf) {
+		r.count++
+	}
+}
+
+func (r *ring) lines() []string {
+	out := make([]string, 0, r.count)
+	start := (r.next - r.count + len(r.buf)) % max(len(r.buf), 1)
+	for i := 0; i < r.count; i++ {
+		out = append(out, r.buf[(start+i)%len(r.buf)])
+	}
+	return out
+}
+
+// tailLines lit les n dernieres lignes en remontant depuis la fin du fichier,
+// par blocs : seule la fin du fichier est lue, quelle que soit sa taille
+func tailLines(path string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("impossible d'ouvrir %s: %w", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	end := info.Size()
+	if end == 0 {
+		return nil, nil
+	}
+
+	sample := make([]byte, min(int64(sampleSize), end))
+	if _, err := f.ReadAt(sample, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("lecture %s: %w", path, err)
+	}
+	enc, bomLen := resolveEncoding(sample, settings.Encoding)
+	if strings.HasPrefix(enc, "utf-16") || detectCompression(sample) != "" {
+		// en UTF-16 un octet 0x0A n'est pas forcement un saut de ligne, et un fichier
+		// compresse ne se lit pas a l'envers : lecture en flux
+		tail := newRing(n)
+		if err := scanLines(path, func(line string) bool { tail.push(line); return true }); err != nil {
+			return nil, err
+		}
+		return tail.lines(), nil
+	}
+	if end <= int64(bomLen) {
+		return nil, nil
+	}
+	// le saut de ligne final ne commence pas une nouvelle ligne
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, end-1); err != nil {
+		return nil, fmt.Errorf("lecture %s: %w", path, err)
+	}
+	if last[0] == '\n' {
+		end--
+	}
+
+	// n lignes completes = n sauts de ligne avant la fin
+	const block = 64 * 1024
+	var chunks [][]byte
+	found, read := 0, int64(0)
+	for pos := end; pos > int64(bomLen) && found < n; {
+		size := min(int64(block), pos-int64(bomLen))
+		pos -= size
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("lecture %s: %w", path, err)
+		}
+		chunks = append(chunks, chunk)
+		found += bytes.Count(chunk, []byte("\n"))
+		read += size
+		if read > int64(found+1)*maxLineSize {
+			return nil, fmt.Errorf("lecture %s: ligne trop longue", path)
+		}
+	}
+
+	var data []byte
+	for i := len(chunks) - 1; i >= 0; i-- {
+		data = append(data, chunks[i]...)
+	}
+	parts := strings.Split(string(data), "\n")
+	if len(parts) > n {
+		parts = parts[len(parts)-n:]
+	}
+	for i, p := range parts {
+		parts[i] = decodeString(strings.TrimSuffix(p, "\r"), enc)
+	}
+	return parts, nil
+}
+
+// lineWriter ecrit des lignes au fil de l'eau dans un fichier de sortie
+type lineWriter struct {
+	*outputFile
+}
+
+func newLineWriter(path string) (*lineWriter, error) {
+	out, err := createOutput(path)
+	if err != nil {
+		return nil, err
+	}
+	return &lineWriter{out}, nil
+}
+
+func (lw *lineWriter) WriteLine(line string) {
+	lw.WriteString(line)
+	lw.WriteByte('\n')
+}
@@ -0,0 +1,79 @@
+package fileops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeSinglePass(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "input.txt")
+	content := "alpha one\nbeta 42\nalpha three\ngamma\n"
+	if err := os.WriteFile(in, []byte(content), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	st, err := Analyze(in, AnalyzeOptions{Filter: FilterOptions{Expr: "ALPHA"}, N: 2, OutDir: tmp})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if st.Lines != 4 || st.Text.Words != 6 || st.Matches != 2 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	if !reflect.DeepEqual(st.Head, []string{"alpha one", "beta 42"}) || !reflect.DeepEqual(st.Tail, []string{"alpha three", "gamma"}) {
+		t.Fatalf("unexpected head/tail: %q %q", st.Head, st.Tail)
+	}
+	filtered, _ := os.ReadFile(filepath.Join(tmp, "filtered.txt"))
+	if string(filtered) != "alpha one\nalpha three\n" {
+		t.Fatalf("unexpected filtered.txt: %q", filtered)
+	}
+}
+
+func TestTailLinesSeeksFromEnd(t *testing.T) {
+	tmp := t.TempDir()
+	cases := []struct {
+		content string
+		n       int
+		want    []string
+	}{
+		{"a\nb\nc\n", 2, []string{"b", "c"}},
+		{"a\nb\nc", 2, []string{"b", "c"}},
+		{"a\r\nb\r\n", 5, []string{"a", "b"}},
+		{"a\n\n", 1, []string{""}},
+		{"", 3, nil},
+	}
+	for i, c := range cases {
+		p := filepath.Join(tmp, fmt.Sprintf("case%d.txt", i))
+		if err := os.WriteFile(p, []byte(c.content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		got, err := tailLines(p, c.n)
+		if err != nil {
+			t.Fatalf("tail %q: %v", c.content, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("tail(%q, %d) = %q, want %q", c.content, c.n, got, c.want)
+		}
+	}
+
+	// plusieurs blocs de 64 Kio
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "line %05d\n", i)
+	}
+	big := filepath.Join(tmp, "big.txt")
+	if err := os.WriteFile(big, []byte(sb.String()), 0644); err != nil {
+		t.Fatalf("write big: %v", err)
+	}
+	got, err := tailLines(big, 8000)
+	if err != nil {
+		t.Fatalf("tail big: %v", err)
+	}
+	if len(got) != 8000 || got[0] != "line 12000" || got[7999] != "line 19999" {
+		t.Fatalf("unexpected big tail: %d lines, first %q", len(got), got[0])
+	}
+}
@@ -14,7 +14,7 @@ type ContainerInfo struct {
 }
 
 func ListContainers() ([]ContainerInfo, error) {
-	cmd := exec.Command("docker", "ps", "--format", "{{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Status}}")
+	cmd := exec.Command(settings.DockerBin, "ps", "--format", "{{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Status}}")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("erreur docker ps (docker lance ?): %w", err)
@@ -37,7 +37,7 @@ func ListContainers() ([]ContainerInfo, error) {
 }
 
 func ContainerStats(nameOrID string) error {
-	cmd := exec.Command("docker", "stats", "--no-stream", "--format",
+	cmd := exec.Command(settings.DockerBin, "stats", "--no-stream", "--format",
 		"table {{.Name}}\t{{.CPUPerc}}\t{{.MemUsage}}\t{{.MemPerc}}", nameOrID)
 	output, err := cmd.Output()
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+
+	"gotools/config"
 )
 
 const (
@@ -14,7 +16,14 @@ const (
 	reset = "\033[0m"
 )
 
-// CheckDiskSpace verifie l'espace disque et affiche une alerte si < 10% libre.
+var settings = config.DefaultConfig().InfraOps
+
+// Configure applique la section infraops de la config
+func Configure(c config.InfraOpsConfig) {
+	settings = c
+}
+
+// CheckDiskSpace verifie l'espace disque et affiche une alerte sous le seuil configure (10% par defaut).
 func CheckDiskSpace() error {
 	used, err := diskSpaceUsedPercent()
 	if err != nil {
@@ -25,7 +34,7 @@ func CheckDiskSpace() error {
 	fmt.Printf("  Espace utilise : %.1f%%\n", used)
 	fmt.Printf("  Espace libre   : %.1f%%\n", free)
 
-	if free < 10 {
+	if free < settings.DiskAlertPercent {
 		fmt.Printf("\n  %sALERTE: Espace disque critique (%.1f%% libre) !%s\n", red, free, reset)
 	} else {
 		fmt.Printf("\n  %sEspace disque: etat normal%s\n", green, reset)
@@ -2,13 +2,19 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"gotools/audit"
 	"gotools/config"
 	"gotools/fileops"
 	"gotools/infraops"
@@ -20,6 +26,13 @@ import (
 var (
 	cfg    *config.Config
 	reader *bufio.Reader
+
+	// --encoding remplace fileops.encoding, y compris apres un rechargement
+	encodingFlag string
+
+	// pendant un suivi (tail -f), Ctrl+C arrete l'action au lieu de quitter
+	interruptMu     sync.Mutex
+	interruptCancel context.CancelFunc
 )
 
 const (
@@ -33,25 +46,77 @@ const (
 
 func main() {
 	configPath := flag.String("config", "", "chemin vers config.txt ou config.json")
+	flag.StringVar(&encodingFlag, "encoding", "", "encodage des fichiers lus : auto, utf-8, utf-16le, utf-16be, latin-1, windows-1252")
 	flag.Parse()
 
+	path := findConfig(*configPath)
 	var err error
-	cfg, err = loadConfig(*configPath)
+	cfg, err = loadConfig(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Erreur config: %v\n", err)
-		fmt.Println("Config par defaut chargee.")
+		fmt.Fprintln(os.Stderr, "Config par defaut chargee.")
 		cfg = config.DefaultConfig()
 	}
+	if encodingFlag != "" {
+		check := *cfg
+		check.FileOps.Encoding = encodingFlag
+		if err := check.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur --encoding: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	applyConfig(cfg)
+
+	// sous-commandes non interactives (ex: gotools config doc)
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
 
 	if err := cfg.EnsureOutDir(); err != nil {
 		fmt.Fprintf(os.Stderr, "Erreur creation dossier out: %v\n", err)
 		os.Exit(1)
 	}
 
+	// rechargement a chaud : modification du fichier ou SIGHUP
+	watcher := config.NewWatcher(path, cfg)
+	watcher.Start(2 * time.Second)
+	defer watcher.Stop()
+	// synchronise et ferme le journal d'audit et ses destinations, y compris sur Ctrl+C
+	defer audit.Close()
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		for s := range sig {
+			interruptMu.Lock()
+			cancel := interruptCancel
+			interruptMu.Unlock()
+			if s == os.Interrupt && cancel != nil {
+				cancel()
+				continue
+			}
+			audit.Close()
+			fmt.Println()
+			if s == syscall.SIGTERM {
+				os.Exit(143) // 128 + 15, comme un shell
+			}
+			os.Exit(130) // 128 + 2 (SIGINT)
+		}
+	}()
+
 	reader = bufio.NewReader(os.Stdin)
 
 	// boucle principale
 	for {
+		// chaque action travaille sur une config stable, la nouvelle est prise au tour suivant
+		if next := watcher.Current(); next != cfg {
+			cfg = next
+			applyConfig(cfg)
+			if err := cfg.EnsureOutDir(); err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur creation dossier out: %v\n", err)
+			}
+		}
+
 		clearScreen()
 		printMenu()
 		choice := readLine(prompt("Choix"))
@@ -73,6 +138,20 @@ func main() {
 			menuHealthCheck()
 		case "H":
 			menuParallelScan()
+		case "I":
+			menuAudit()
+		case "J":
+			menuFollow()
+		case "K":
+			menuLogs()
+		case "L":
+			menuIncidents()
+		case "M":
+			menuDiff()
+		case "N":
+			menuDuplicates()
+		case "O":
+			menuNgrams()
 		case "Q":
 			fmt.Println(success("Au revoir !"))
 			return
@@ -83,18 +162,42 @@ func main() {
 	}
 }
 
-func loadConfig(path string) (*config.Config, error) {
+// findConfig renvoie le fichier de config a utiliser ("" si aucun)
+func findConfig(path string) string {
 	if path != "" {
-		return config.Load(path)
+		return path
 	}
 	// on tente json d'abord, sinon txt
-	if _, err := os.Stat("config.json"); err == nil {
-		return config.Load("config.json")
+	for _, p := range []string{"config.json", "config.txt"} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
 	}
-	if _, err := os.Stat("config.txt"); err == nil {
-		return config.Load("config.txt")
+	return ""
+}
+
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return nil, fmt.Errorf("aucun fichier de config trouve")
 	}
-	return nil, fmt.Errorf("aucun fichier de config trouve")
+	return config.Load(path)
+}
+
+// applyConfig transmet les sections de la config a chaque package
+func applyConfig(c *config.Config) {
+	fo := c.FileOps
+	if fo.Language == "auto" {
+		fo.Language = c.WikiLang
+	}
+	if encodingFlag != "" {
+		fo.Encoding = encodingFlag
+	}
+	fileops.Configure(fo)
+	webops.Configure(c.WebOps)
+	procops.Configure(c.ProcOps)
+	secureops.Configure(c.SecureOps)
+	infraops.Configure(c.InfraOps)
+	audit.Configure(c.Audit)
 }
 
 func printMenu() {
@@ -108,6 +211,13 @@ func printMenu() {
 		"[F] InfraOps  Docker",
 		"[G] InfraOps  Etat disque",
 		"[H] InfraOps  Scan parallele (.txt)",
+		"[I] Audit     Journal des actions",
+		"[J] FileOps   Suivi de fichiers (tail -f)",
+		"[K] FileOps   Analyse de logs",
+		"[L] FileOps   Detection d'incidents (pics d'erreurs)",
+		"[M] FileOps   Comparer deux fichiers ou deux rapports",
+		"[N] FileOps   Lignes en double (uniq -c, dedoublonnage)",
+		"[O] FileOps   N-grammes et collocations",
 		"[Q] Quitter",
 	})
 }
@@ -122,40 +232,49 @@ func menuAnalysis() {
 		return
 	}
 
-	fmt.Println("\n--- Infos fichier ---")
-	if err := fileops.FileInfo(path); err != nil {
+	// tout est demande avant : le fichier n'est lu qu'une fois
+	filter := readFilterOptions()
+	n := readIntMin("Nombre de lignes pour head/tail", 5, 0)
+
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
 		fmt.Println(failure("Erreur: " + err.Error()))
 		return
 	}
+	defer endRun(run)
 
-	fmt.Println("\n--- Stats mots ---")
-	if err := fileops.WordStats(path); err != nil {
+	opts := fileops.AnalyzeOptions{Filter: filter, N: n, OutDir: run.Dir, TopWords: cfg.FileOps.TopWords, Vocabulary: true}
+	st, err := fileops.Analyze(path, opts)
+	if err != nil {
 		fmt.Println(failure("Erreur: " + err.Error()))
 		return
 	}
+	fileops.PrintStats(st, opts)
+}
 
-	keyword := readLine("Mot-cle pour filtrage (optionnel) : ")
-	if keyword != "" {
-		fmt.Println("\n--- Comptage ---")
-		if _, err := fileops.CountKeyword(path, keyword); err != nil {
-			fmt.Println(failure("Erreur: " + err.Error()))
-		}
-
-		fmt.Println("\n--- Filtrage ---")
-		if err := fileops.FilterKeyword(path, keyword, cfg.OutDir); err != nil {
-			fmt.Println(failure("Erreur: " + err.Error()))
-		}
+// endRun ecrit le manifeste des fichiers produits par l'execution
+func endRun(run *fileops.Run) {
+	if err := run.End(); err != nil {
+		fmt.Println(failure("Erreur manifeste: " + err.Error()))
 	}
+}
 
-	n := readIntMin("Nombre de lignes pour head/tail", 5, 0)
-	fmt.Println("\n--- Head ---")
-	if err := fileops.Head(path, n, cfg.OutDir); err != nil {
-		fmt.Println(failure("Erreur: " + err.Error()))
-	}
-	fmt.Println("\n--- Tail ---")
-	if err := fileops.Tail(path, n, cfg.OutDir); err != nil {
-		fmt.Println(failure("Erreur: " + err.Error()))
-	}
+// readFilterOptions demande l'expression de filtrage et ses options (vide = pas de filtre)
+func readFilterOptions() fileops.FilterOptions {
+	o := fileops.FilterOptions{Show: 20, Color: useColor()}
+	o.Expr = readLine("Filtre (optionnel, ex: error AND NOT debug, warn OR error)")
+	if o.Expr == "" {
+		return o
+	}
+	flags := strings.ToLower(readLineDefault("Options (r=regex, c=casse, w=mot entier)", "aucune"))
+	if flags != "aucune" {
+		o.Regex = strings.Contains(flags, "r")
+		o.CaseSensitive = strings.Contains(flags, "c")
+		o.WholeWord = strings.Contains(flags, "w")
+	}
+	o.Before = readIntMin("Lignes de contexte avant (-B)", 0, 0)
+	o.After = readIntMin("Lignes de contexte apres (-A)", 0, 0)
+	return o
 }
 
 // ---- Choix B ----
@@ -168,10 +287,18 @@ func menuMultiFiles() {
 		return
 	}
 
+	run, err := fileops.BeginRun(cfg.OutDir, dir)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	defer endRun(run)
+
 	runStep("Batch", func() error { return fileops.BatchAnalyze(dir) })
-	runStep("Rapport", func() error { return fileops.GenerateReport(dir, cfg.OutDir) })
-	runStep("Index", func() error { return fileops.GenerateIndex(dir, cfg.OutDir) })
-	runStep("Fusion", func() error { return fileops.MergeFiles(dir, cfg.OutDir) })
+	runStep("Rapport", func() error { return fileops.GenerateReport(dir, run.Dir) })
+	runStep("Index", func() error { return fileops.GenerateIndex(dir, run.Dir) })
+	runStep("Fusion", func() error { return fileops.MergeFiles(dir, run.Dir) })
+	runStep("Frequence des mots", func() error { return fileops.FrequencyReport(dir, run.Dir) })
 }
 
 // ---- Choix C ----
@@ -331,7 +458,7 @@ func menuParallelScan() {
 		return
 	}
 	if len(files) == 0 {
-		fmt.Println(failure("Aucun fichier .txt trouve."))
+		fmt.Println(failure("Aucun fichier " + strings.Join(cfg.FileOps.Extensions, "/") + " trouve."))
 		return
 	}
 
@@ -351,17 +478,12 @@ func menuParallelScan() {
 		wg.Add(1)
 		go func(p string) {
 			defer wg.Done()
-			lines, err := fileops.ReadLines(p)
-			if err != nil {
-				ch <- scanResult{path: p, err: err}
-				return
-			}
-			words, err := fileops.ExtractWords(p)
+			st, err := fileops.Analyze(p, fileops.AnalyzeOptions{})
 			if err != nil {
 				ch <- scanResult{path: p, err: err}
 				return
 			}
-			ch <- scanResult{path: p, lines: len(lines), words: len(words)}
+			ch <- scanResult{path: p, lines: st.Lines, words: st.Text.Words}
 		}(f)
 	}
 
@@ -382,6 +504,268 @@ func menuParallelScan() {
 	}
 }
 
+// ---- Choix I ----
+
+func menuAudit() {
+	path := filepath.Join(cfg.OutDir, cfg.Audit.File)
+	for {
+		printPanel("Audit", []string{
+			"[1] Derniers evenements",
+			"[2] Rechercher (action, cible, periode)",
+			"[3] Verifier l'integrite du journal",
+			"[R] Retour",
+		})
+
+		switch strings.ToUpper(readLine(prompt("Choix"))) {
+		case "1":
+			n := readIntMin("  Nombre d'evenements", 20, 1)
+			showAuditEvents(path, audit.Filter{Last: n})
+
+		case "2":
+			f := audit.Filter{Last: 50}
+			for _, a := range strings.Split(readLine("  Action(s) KILL,LOCK,UNLOCK,CHMOD (vide = toutes)"), ",") {
+				if a = strings.TrimSpace(a); a != "" {
+					f.Actions = append(f.Actions, audit.Action(strings.ToUpper(a)))
+				}
+			}
+			f.Target = readLine("  Cible (chemin ou PID, vide = toutes)")
+			if o := strings.TrimSpace(readLine("  Resultat success/failure/cancelled (vide = tous)")); o != "" {
+				f.Outcomes = []audit.Outcome{audit.Outcome(strings.ToLower(o))}
+			}
+			since := readLineDefault("  Depuis (2026-10-12, 24h, 7d)", "7d")
+			t, err := audit.ParseTime(since, time.Now())
+			if err != nil {
+				fmt.Println(failure("Erreur: " + err.Error()))
+				continue
+			}
+			f.Since = t
+			showAuditEvents(path, f)
+
+		case "3":
+			rep, err := audit.Verify(path, cfg.Audit.HMACKey)
+			if err != nil {
+				fmt.Println(failure("Erreur: " + err.Error()))
+				continue
+			}
+			if rep.Problem != nil {
+				fmt.Println(failure(rep.Problem.String()))
+			} else {
+				fmt.Println(success(fmt.Sprintf("%d entree(s) verifiee(s), chaine intacte", rep.Entries)))
+			}
+
+		case "R":
+			return
+		default:
+			fmt.Println(failure("Choix invalide."))
+		}
+	}
+}
+
+func showAuditEvents(path string, f audit.Filter) {
+	events, err := audit.Query(path, f)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	if len(events) == 0 {
+		fmt.Println(failure("Aucun evenement."))
+		return
+	}
+	if err := audit.WriteEvents(os.Stdout, events, "table"); err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+	}
+}
+
+// ---- Choix J ----
+
+func menuFollow() {
+	printSection("FileOps - Suivi (tail -f)")
+	paths := strings.Fields(readLineDefault("Fichier(s) a suivre (separes par des espaces)", cfg.DefaultFile))
+	filter := readFilterOptions()
+	n := readIntMin("Dernieres lignes affichees au depart", 10, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interruptMu.Lock()
+	interruptCancel = cancel
+	interruptMu.Unlock()
+	defer func() {
+		interruptMu.Lock()
+		interruptCancel = nil
+		interruptMu.Unlock()
+	}()
+
+	fmt.Println("Suivi en cours, Ctrl+C pour revenir au menu.")
+	if err := fileops.Follow(ctx, paths, fileops.FollowOptions{Filter: filter, Lines: n}, os.Stdout); err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	fmt.Println()
+	fmt.Println(success("Suivi arrete."))
+}
+
+// ---- Choix K ----
+
+func menuLogs() {
+	printSection("FileOps - Analyse de logs")
+	path := readLineDefault("Fichier de log", cfg.DefaultFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println(failure(fmt.Sprintf("Erreur: '%s' introuvable.", path)))
+		return
+	}
+	format := readLineDefault("Format (auto, json, logfmt, syslog, combined ou format perso)", cfg.FileOps.LogFormat)
+
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	defer endRun(run)
+
+	st, err := fileops.AnalyzeLog(path, format, run.Dir)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	fileops.PrintLogStats(st, cfg.FileOps.TopValues)
+}
+
+func menuIncidents() {
+	printSection("FileOps - Detection d'incidents")
+	path := readLineDefault("Fichier de log", cfg.DefaultFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println(failure(fmt.Sprintf("Erreur: '%s' introuvable.", path)))
+		return
+	}
+	format := readLineDefault("Format (auto, json, logfmt, syslog, combined ou format perso)", cfg.FileOps.LogFormat)
+	bucket, err := fileops.ParseBucket(readLineDefault("Tranche (minute ou hour)", cfg.FileOps.IncidentBucket))
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	level := readLineDefault("Niveau minimal compte comme erreur", "ERROR")
+	expr := readLineDefault("Filtre a compter a la place du niveau (vide = niveau)", "")
+
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	defer endRun(run)
+
+	rep, err := fileops.DetectIncidents(path, fileops.IncidentOptions{
+		Format: format, Bucket: bucket, MinLevel: level,
+		Filter:    fileops.FilterOptions{Expr: expr},
+		Threshold: cfg.FileOps.IncidentThreshold,
+		MinHits:   cfg.FileOps.IncidentMinErrors,
+		Samples:   cfg.FileOps.IncidentSamples,
+	}, run.Dir)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	fileops.PrintIncidentReport(rep)
+}
+
+func menuDiff() {
+	printSection("FileOps - Comparaison")
+	fmt.Println("  1) Deux fichiers (lignes)")
+	fmt.Println("  2) Deux rapports du menu B (report.txt)")
+	choice := readLineDefault("Choix", "1")
+	a := readLineDefault("Premier fichier (avant)", cfg.DefaultFile)
+	b := readLineDefault("Second fichier (apres)", "")
+	for _, p := range []string{a, b} {
+		if _, err := os.Stat(p); err != nil {
+			fmt.Println(failure(fmt.Sprintf("Erreur: '%s' introuvable.", p)))
+			return
+		}
+	}
+
+	run, err := fileops.BeginRun(cfg.OutDir, b)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	defer endRun(run)
+
+	if choice == "2" {
+		d, err := fileops.DiffReports(a, b, run.Dir)
+		if err != nil {
+			fmt.Println(failure("Erreur: " + err.Error()))
+			return
+		}
+		fileops.PrintReportDiff(d)
+		return
+	}
+	o := fileops.DiffOptions{Color: useColor()}
+	flags := strings.ToLower(readLineDefault("Options (s=cote a cote, e=ignorer les espaces, c=ignorer la casse)", "aucune"))
+	if flags != "aucune" {
+		o.SideBySide = strings.Contains(flags, "s")
+		o.IgnoreSpace = strings.Contains(flags, "e")
+		o.IgnoreCase = strings.Contains(flags, "c")
+	}
+	o.Context = readIntMin("Lignes de contexte", 3, 0)
+	res, err := fileops.DiffFiles(a, b, o, run.Dir)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	fileops.PrintDiff(res)
+}
+
+func menuDuplicates() {
+	printSection("FileOps - Lignes en double")
+	path := readLineDefault("Fichier a analyser", cfg.DefaultFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println(failure(fmt.Sprintf("Erreur: '%s' introuvable.", path)))
+		return
+	}
+	var o fileops.DedupOptions
+	flags := strings.ToLower(readLineDefault("Options (n=normaliser nombres/dates/UUID, c=ignorer la casse)", "aucune"))
+	if flags != "aucune" {
+		o.Normalize = strings.Contains(flags, "n")
+		o.IgnoreCase = strings.Contains(flags, "c")
+	}
+
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	defer endRun(run)
+
+	res, err := fileops.FindDuplicates(path, o, run.Dir)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	fileops.PrintDuplicates(res, cfg.FileOps.TopValues)
+}
+
+func menuNgrams() {
+	printSection("FileOps - N-grammes et collocations")
+	path := readLineDefault("Fichier ou dossier", cfg.BaseDir)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println(failure(fmt.Sprintf("Erreur: '%s' introuvable.", path)))
+		return
+	}
+	minCount := readIntMin("Occurrences minimum", cfg.FileOps.NgramMinCount, 1)
+
+	run, err := fileops.BeginRun(cfg.OutDir, path)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	defer endRun(run)
+
+	res, err := fileops.NgramReport(path, fileops.NgramOptions{MinCount: minCount}, run.Dir)
+	if err != nil {
+		fmt.Println(failure("Erreur: " + err.Error()))
+		return
+	}
+	fileops.PrintNgrams(res, cfg.FileOps.TopValues)
+}
+
 // ---- saisie utilisateur ----
 
 func runStep(title string, fn func() error) {
@@ -438,8 +822,14 @@ func waitForContinue() {
 	_, _ = reader.ReadString('\n')
 }
 
+// useColor : couleurs seulement vers un terminal, jamais dans un pipe ou un
+// fichier redirige (gotools diff a b > x.patch)
 func useColor() bool {
-	return os.Getenv("TERM") != "" && os.Getenv("NO_COLOR") == ""
+	if os.Getenv("TERM") == "" || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 func colorize(s, color string) string {
@@ -10,8 +10,16 @@ import (
 	"strings"
 
 	"gotools/audit"
+	"gotools/config"
 )
 
+var settings = config.DefaultConfig().ProcOps
+
+// Configure applique la section procops de la config
+func Configure(c config.ProcOpsConfig) {
+	settings = c
+}
+
 type Process struct {
 	PID  int
 	Name string
@@ -53,26 +61,34 @@ func SearchProcesses(keyword string, topN int) ([]Process, error) {
 
 // KillProcess demande confirmation avant de tuer un processus.
 func KillProcess(pid int, outDir string, reader *bufio.Reader) error {
+	ev := audit.Start(audit.ActionKill, strconv.Itoa(pid)).
+		With("signal", settings.KillSignal)
 	if pid <= 0 {
-		return fmt.Errorf("PID invalide: %d", pid)
+		err := fmt.Errorf("PID invalide: %d", pid)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
 	}
 
 	name := findProcessName(pid)
+	ev.Was("name", name)
 	fmt.Printf("  Processus : PID=%d  Nom=%s\n", pid, name)
 	fmt.Print("  Confirmer l'arret ? (yes/no ou oui/non) : ")
 
 	answer, _ := reader.ReadString('\n')
 	if !isConfirmed(answer) {
+		ev.Finish(outDir, audit.OutcomeCancelled, nil)
 		fmt.Println("  Action annulee.")
 		return nil
 	}
 
-	cmd := killProcessCmd(runtime.GOOS, pid)
+	cmd := killProcessCmd(runtime.GOOS, pid, settings.KillSignal)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("impossible d'arreter PID %d: %w", pid, err)
+		err = fmt.Errorf("impossible d'arreter PID %d: %w", pid, err)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
 	}
 
-	audit.Log(outDir, fmt.Sprintf("KILL PID=%d (%s)", pid, name))
+	ev.Finish(outDir, audit.OutcomeSuccess, nil)
 	fmt.Printf("  Processus %d termine.\n", pid)
 	return nil
 }
@@ -97,12 +113,16 @@ func listProcessesCmd(goos string) *exec.Cmd {
 	}
 }
 
-func killProcessCmd(goos string, pid int) *exec.Cmd {
+// killProcessCmd construit la commande d'arret, signal n'est utilise que sur Unix
+func killProcessCmd(goos string, pid int, signal string) *exec.Cmd {
 	pidStr := strconv.Itoa(pid)
 	if goos == "windows" {
 		return exec.Command("taskkill", "/PID", pidStr, "/T")
 	}
-	return exec.Command("kill", pidStr)
+	if signal == "" {
+		signal = "TERM"
+	}
+	return exec.Command("kill", "-s", signal, pidStr)
 }
 
 func parseProcesses(output, goos string) []Process {
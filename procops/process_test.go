@@ -1,6 +1,11 @@
 package procops
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+
+	"gotools/audit"
+)
 
 func TestParseWindowsLine(t *testing.T) {
 	line := `"Code.exe","1234","Console","1","12,000 K"`
@@ -48,3 +53,22 @@ func TestIsConfirmed(t *testing.T) {
 		}
 	}
 }
+
+func TestKillInvalidPIDAudited(t *testing.T) {
+	tmp := t.TempDir()
+	if err := KillProcess(0, tmp, nil); err == nil {
+		t.Fatal("expected error on invalid PID")
+	}
+
+	events, err := audit.Query(filepath.Join(tmp, "audit.log"), audit.Filter{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d: %+v", len(events), events)
+	}
+	ev := events[0]
+	if ev.Action != audit.ActionKill || ev.Target != "0" || ev.Outcome != audit.OutcomeFailure || ev.Error != "PID invalide: 0" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
@@ -8,63 +8,88 @@ import (
 	"strings"
 
 	"gotools/audit"
+	"gotools/config"
 )
 
-// LockFile cree un fichier .lock pour simuler le verrouillage
+var settings = config.DefaultConfig().SecureOps
+
+// Configure applique la section secureops de la config
+func Configure(c config.SecureOpsConfig) {
+	settings = c
+}
+
+// LockFile cree un fichier .lock pour simuler le verrouillage ; le lock contient
+// son proprietaire. Chaque tentative est journalisee, refus et echecs compris.
 func LockFile(filename, outDir string, reader *bufio.Reader) error {
+	lockPath := filepath.Join(outDir, filepath.Base(filename)+".lock")
+	ev := audit.Start(audit.ActionLock, filename).With("lock", lockPath)
+
 	info, err := os.Stat(filename)
 	if err != nil {
-		return fmt.Errorf("fichier introuvable: %w", err)
+		err = fmt.Errorf("fichier introuvable: %w", err)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
 	}
 	if info.IsDir() {
-		return fmt.Errorf("%s est un dossier, pas un fichier", filename)
+		err = fmt.Errorf("%s est un dossier, pas un fichier", filename)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
 	}
+	ev.Was("mode", config.FileMode(info.Mode().Perm()).String())
 
-	lockPath := filepath.Join(outDir, filepath.Base(filename)+".lock")
-
-	if _, err := os.Stat(lockPath); err == nil {
-		fmt.Printf("  '%s' est deja verrouille.\n", filename)
+	if owner, locked := lockOwner(lockPath); locked {
+		ev.Was("lock_owner", owner)
+		ev.Finish(outDir, audit.OutcomeFailure, fmt.Errorf("deja verrouille par %s", owner))
+		fmt.Printf("  '%s' est deja verrouille (%s).\n", filename, owner)
 		return nil
 	}
 
 	fmt.Printf("  Verrouiller '%s' ? (yes/no ou oui/non) : ", filename)
 	answer, _ := reader.ReadString('\n')
 	if !isConfirmed(answer) {
+		ev.Finish(outDir, audit.OutcomeCancelled, nil)
 		fmt.Println("  Action annulee.")
 		return nil
 	}
 
-	f, err := os.Create(lockPath)
-	if err != nil {
-		return fmt.Errorf("impossible de creer le lock: %w", err)
+	if err := os.WriteFile(lockPath, []byte(audit.Owner()+"\n"), 0644); err != nil {
+		err = fmt.Errorf("impossible de creer le lock: %w", err)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
 	}
-	f.Close()
 
-	audit.Log(outDir, fmt.Sprintf("LOCK %s", filename))
+	ev.Finish(outDir, audit.OutcomeSuccess, nil)
 	fmt.Printf("  '%s' verrouille.\n", filename)
 	return nil
 }
 
 func UnlockFile(filename, outDir string, reader *bufio.Reader) error {
 	lockPath := filepath.Join(outDir, filepath.Base(filename)+".lock")
+	ev := audit.Start(audit.ActionUnlock, filename).With("lock", lockPath)
 
-	if _, err := os.Stat(lockPath); os.IsNotExist(err) {
+	owner, locked := lockOwner(lockPath)
+	if !locked {
+		ev.Finish(outDir, audit.OutcomeFailure, fmt.Errorf("pas verrouille"))
 		fmt.Printf("  '%s' n'est pas verrouille.\n", filename)
 		return nil
 	}
+	ev.Was("lock_owner", owner)
 
-	fmt.Printf("  Deverrouiller '%s' ? (yes/no ou oui/non) : ", filename)
+	fmt.Printf("  Deverrouiller '%s' (lock de %s) ? (yes/no ou oui/non) : ", filename, owner)
 	answer, _ := reader.ReadString('\n')
 	if !isConfirmed(answer) {
+		ev.Finish(outDir, audit.OutcomeCancelled, nil)
 		fmt.Println("  Action annulee.")
 		return nil
 	}
 
 	if err := os.Remove(lockPath); err != nil {
-		return fmt.Errorf("impossible de supprimer le lock: %w", err)
+		err = fmt.Errorf("impossible de supprimer le lock: %w", err)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
 	}
 
-	audit.Log(outDir, fmt.Sprintf("UNLOCK %s", filename))
+	ev.Finish(outDir, audit.OutcomeSuccess, nil)
 	fmt.Printf("  '%s' deverrouille.\n", filename)
 	return nil
 }
@@ -75,24 +100,52 @@ func IsLocked(filename, outDir string) bool {
 	return err == nil
 }
 
+// lockOwner lit le proprietaire inscrit dans le lock ; les anciens locks sont vides
+func lockOwner(lockPath string) (string, bool) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return "", false
+	}
+	owner := strings.TrimSpace(string(data))
+	if owner == "" {
+		owner = "(inconnu)"
+	}
+	return owner, true
+}
+
 func SetReadOnly(path, outDir string) error {
-	if err := os.Chmod(path, 0444); err != nil {
-		return fmt.Errorf("chmod impossible: %w", err)
+	if err := setMode(path, outDir, settings.ReadOnlyMode, "read-only"); err != nil {
+		return err
 	}
-	audit.Log(outDir, fmt.Sprintf("CHMOD read-only %s", path))
 	fmt.Printf("  '%s' passe en lecture seule.\n", path)
 	return nil
 }
 
 func SetReadWrite(path, outDir string) error {
-	if err := os.Chmod(path, 0644); err != nil {
-		return fmt.Errorf("chmod impossible: %w", err)
+	if err := setMode(path, outDir, settings.ReadWriteMode, "read-write"); err != nil {
+		return err
 	}
-	audit.Log(outDir, fmt.Sprintf("CHMOD read-write %s", path))
 	fmt.Printf("  '%s' passe en lecture/ecriture.\n", path)
 	return nil
 }
 
+// setMode applique mode et journalise l'ancien mode avec le resultat
+func setMode(path, outDir string, mode config.FileMode, access string) error {
+	ev := audit.Start(audit.ActionChmod, path).
+		With("mode", mode.String()).
+		With("access", access)
+	if info, err := os.Stat(path); err == nil {
+		ev.Was("mode", config.FileMode(info.Mode().Perm()).String())
+	}
+	if err := os.Chmod(path, mode.Perm()); err != nil {
+		err = fmt.Errorf("chmod impossible: %w", err)
+		ev.Finish(outDir, audit.OutcomeFailure, err)
+		return err
+	}
+	ev.Finish(outDir, audit.OutcomeSuccess, nil)
+	return nil
+}
+
 func CheckPermissions(path string) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"gotools/audit"
 )
 
 func TestLockUnlockLifecycle(t *testing.T) {
@@ -45,3 +47,59 @@ func TestSetReadOnlyReadWrite(t *testing.T) {
 		t.Fatalf("set readwrite: %v", err)
 	}
 }
+
+func TestEveryAttemptAudited(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "audited.txt")
+	if err := os.WriteFile(file, []byte("x"), 0640); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	r := bufio.NewReader(strings.NewReader("no\nyes\nyes\n"))
+	LockFile(file, tmp, r) // refuse
+	LockFile(file, tmp, r) // accepte
+	LockFile(file, tmp, r) // deja verrouille
+	UnlockFile(file, tmp, r)
+	UnlockFile(file, tmp, r) // pas verrouille
+	if err := SetReadOnly(filepath.Join(tmp, "missing.txt"), tmp); err == nil {
+		t.Fatal("expected chmod error on missing file")
+	}
+	SetReadOnly(file, tmp)
+
+	events, err := audit.Query(filepath.Join(tmp, "audit.log"), audit.Filter{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	want := []struct {
+		action  audit.Action
+		outcome audit.Outcome
+	}{
+		{audit.ActionLock, audit.OutcomeCancelled},
+		{audit.ActionLock, audit.OutcomeSuccess},
+		{audit.ActionLock, audit.OutcomeFailure},
+		{audit.ActionUnlock, audit.OutcomeSuccess},
+		{audit.ActionUnlock, audit.OutcomeFailure},
+		{audit.ActionChmod, audit.OutcomeFailure},
+		{audit.ActionChmod, audit.OutcomeSuccess},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, w := range want {
+		if events[i].Action != w.action || events[i].Outcome != w.outcome {
+			t.Fatalf("event %d: got %s/%s, want %s/%s", i, events[i].Action, events[i].Outcome, w.action, w.outcome)
+		}
+	}
+	if owner := events[3].Before["lock_owner"]; owner != audit.Owner() {
+		t.Fatalf("unlock should record lock owner, got %q", owner)
+	}
+	if events[2].Error == "" || events[4].Error == "" {
+		t.Fatal("refused lock and unlock should record why")
+	}
+	if events[5].Error == "" {
+		t.Fatal("failed chmod should record its error")
+	}
+	if events[6].Before["mode"] != "0640" || events[6].Params["mode"] != "0444" {
+		t.Fatalf("chmod snapshot: before=%v params=%v", events[6].Before, events[6].Params)
+	}
+}
@@ -0,0 +1,221 @@
+package tokenizer
+
+import (
+	"hash/maphash"
+	"strings"
+	"unicode/utf8"
+)
+
+// Metrics mesure la prose d'un texte : phrases, paragraphes, diversite du
+// vocabulaire et lisibilite. Les lignes sont ajoutees une a une (fichiers lus
+// en flux) ; un paragraphe est un bloc de lignes non vides.
+type Metrics struct {
+	Paragraphs int
+	Words      int
+
+	// Vocabulary active le comptage des mots distincts (Types, TTR). Desactive
+	// par defaut : la memoire croit avec le vocabulaire, jusqu'a maxTypes mots.
+	Vocabulary bool
+
+	sentences int
+	pending   int    // mots de la phrase en cours
+	last      string // dernier mot, pour les abreviations
+	inPara    bool
+	sylEn     int
+	sylFr     int
+	seed      maphash.Seed
+	types     map[uint64]struct{} // empreintes des mots distincts (en minuscules)
+	capped    bool
+}
+
+// maxTypes borne la memoire du vocabulaire (environ 40 Mo)
+const maxTypes = 1 << 20
+
+// AddLine ajoute une ligne (sans son saut de ligne)
+func (m *Metrics) AddLine(line string) {
+	if m.beginLine(line) {
+		Each(line, m.add)
+	}
+}
+
+// AddText ajoute un texte complet, ligne par ligne
+func (m *Metrics) AddText(text string) {
+	for _, line := range strings.Split(text, "\n") {
+		m.AddLine(line)
+	}
+}
+
+// beginLine tient a jour les paragraphes ; faux pour une ligne vide
+func (m *Metrics) beginLine(line string) bool {
+	if strings.TrimSpace(line) == "" {
+		m.endSentence() // un titre ou un element de liste sans point compte comme phrase
+		m.inPara = false
+		return false
+	}
+	if !m.inPara {
+		m.Paragraphs++
+		m.inPara = true
+	}
+	return true
+}
+
+func (m *Metrics) add(t Token) {
+	switch t.Kind {
+	case Word:
+		m.Words++
+		m.pending++
+		m.last = t.Text
+		en, fr := syllables(t.Text)
+		m.sylEn += en
+		m.sylFr += fr
+		if m.Vocabulary {
+			m.addType(t.Text)
+		}
+	case Number:
+		m.last = "" // "en 2024." termine la phrase
+	case Punct:
+		if sentenceEnds[t.Text] && !isAbbreviation(m.last) {
+			m.endSentence()
+		}
+	}
+}
+
+func (m *Metrics) addType(w string) {
+	if m.types == nil {
+		m.seed = maphash.MakeSeed()
+		m.types = map[uint64]struct{}{}
+	}
+	h := maphash.String(m.seed, strings.ToLower(w))
+	if _, ok := m.types[h]; ok {
+		return
+	}
+	if len(m.types) >= maxTypes {
+		m.capped = true
+		return
+	}
+	m.types[h] = struct{}{}
+}
+
+func (m *Metrics) endSentence() {
+	if m.pending > 0 {
+		m.sentences++
+		m.pending = 0
+	}
+}
+
+var sentenceEnds = map[string]bool{".": true, "!": true, "?": true, "…": true, "。": true, "！": true, "？": true}
+
+// abreviations suivies d'un point qui ne terminent pas la phrase
+var abbreviations = map[string]bool{
+	"m": true, "mme": true, "mlle": true, "mm": true, "dr": true, "pr": true, "st": true,
+	"mr": true, "mrs": true, "ms": true, "vs": true, "cf": true, "ex": true,
+}
+
+// isAbbreviation : initiale ("J. Dupont") ou titre courant ("M.", "Dr.")
+func isAbbreviation(w string) bool {
+	return utf8.RuneCountInString(w) == 1 || abbreviations[strings.ToLower(w)]
+}
+
+// Sentences renvoie le nombre de phrases, la derniere pouvant ne pas avoir de point
+func (m *Metrics) Sentences() int {
+	if m.pending > 0 {
+		return m.sentences + 1
+	}
+	return m.sentences
+}
+
+// AvgSentenceLen renvoie le nombre moyen de mots par phrase
+func (m *Metrics) AvgSentenceLen() float64 {
+	if n := m.Sentences(); n > 0 {
+		return float64(m.Words) / float64(n)
+	}
+	return 0
+}
+
+// Types renvoie le nombre de mots distincts (casse ignoree), 0 sans Vocabulary
+func (m *Metrics) Types() int { return len(m.types) }
+
+// TypesCapped indique que le plafond maxTypes est atteint : Types et TTR ne
+// sont plus que des minimums
+func (m *Metrics) TypesCapped() bool { return m.capped }
+
+// TTR renvoie la diversite lexicale : mots distincts / mots (1 = aucun mot
+// repete). Elle baisse mecaniquement avec la longueur du texte.
+func (m *Metrics) TTR() float64 {
+	if m.Words == 0 {
+		return 0
+	}
+	return float64(len(m.types)) / float64(m.Words)
+}
+
+// Syllables renvoie le nombre estime de syllabes pour la langue ("fr" ou anglais)
+func (m *Metrics) Syllables(lang string) int {
+	if lang == "fr" {
+		return m.sylFr
+	}
+	return m.sylEn
+}
+
+// Readability renvoie le score de lisibilite et le nom de la formule : Kandel-Moles
+// pour le francais, Flesch sinon. 0-100, plus c'est haut plus le texte est facile.
+func (m *Metrics) Readability(lang string) (float64, string) {
+	n := m.Sentences()
+	if m.Words == 0 || n == 0 {
+		return 0, ""
+	}
+	wps := float64(m.Words) / float64(n)
+	spw := float64(m.Syllables(lang)) / float64(m.Words)
+	if lang == "fr" {
+		return 207 - 1.015*wps - 73.6*spw, "Kandel-Moles"
+	}
+	return 206.835 - 1.015*wps - 84.6*spw, "Flesch"
+}
+
+// ReadabilityLabel qualifie un score de Flesch ou Kandel-Moles
+func ReadabilityLabel(score float64) string {
+	switch {
+	case score >= 90:
+		return "tres facile"
+	case score >= 80:
+		return "facile"
+	case score >= 70:
+		return "assez facile"
+	case score >= 60:
+		return "standard"
+	case score >= 50:
+		return "assez difficile"
+	case score >= 30:
+		return "difficile"
+	}
+	return "tres difficile"
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouyàâäéèêëîïôöùûüÿæœ", r)
+}
+
+// syllables estime les syllabes d'un mot : groupes de voyelles, moins le e
+// final muet ("made" : 1 en anglais ; "table" : 2 en anglais, 1 en francais)
+func syllables(word string) (en, fr int) {
+	w := []rune(strings.ToLower(word))
+	groups, prev := 0, false
+	for _, r := range w {
+		v := isVowel(r)
+		if v && !prev {
+			groups++
+		}
+		prev = v
+	}
+	en, fr = groups, groups
+	n := len(w)
+	if groups > 1 && w[n-1] == 'e' {
+		fr--
+		// "-le" apres consonne se prononce en anglais ("table", "little")
+		if !(n > 2 && w[n-2] == 'l' && !isVowel(w[n-3])) {
+			en--
+		}
+	} else if groups > 1 && n > 2 && w[n-1] == 's' && w[n-2] == 'e' {
+		fr--
+	}
+	return max(en, 1), max(fr, 1)
+}
@@ -0,0 +1,145 @@
+package tokenizer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharStats compte les caracteres par classe Unicode
+type CharStats struct {
+	Runes   int
+	Letters int
+	Upper   int
+	Lower   int
+	Digits  int
+	Spaces  int
+	Punct   int
+	Symbols int
+	Other   int // controles, marques, caracteres invalides
+}
+
+// Add compte les caracteres de s
+func (c *CharStats) Add(s string) {
+	for _, r := range s {
+		c.Runes++
+		switch {
+		case unicode.IsLetter(r):
+			c.Letters++
+			if unicode.IsUpper(r) {
+				c.Upper++
+			} else if unicode.IsLower(r) {
+				c.Lower++
+			}
+		case unicode.IsDigit(r):
+			c.Digits++
+		case unicode.IsSpace(r):
+			c.Spaces++
+		case unicode.IsPunct(r):
+			c.Punct++
+		case unicode.IsSymbol(r):
+			c.Symbols++
+		default:
+			c.Other++
+		}
+	}
+}
+
+// bornes hautes des tranches de l'histogramme (en caracteres), la derniere est ouverte
+var histBounds = []int{0, 20, 40, 80, 120, 200}
+
+// Histogram repartit les lignes selon leur longueur en caracteres
+type Histogram struct {
+	Counts [7]int // 0, 1-20, 21-40, 41-80, 81-120, 121-200, >200
+	Max    int    // ligne la plus longue
+}
+
+// Add compte une ligne de n caracteres
+func (h *Histogram) Add(n int) {
+	i := 0
+	for i < len(histBounds) && n > histBounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	if n > h.Max {
+		h.Max = n
+	}
+}
+
+// Label renvoie le libelle de la tranche i
+func (h *Histogram) Label(i int) string {
+	switch {
+	case i == 0:
+		return "vide"
+	case i == len(histBounds):
+		return fmt.Sprintf(">%d", histBounds[i-1])
+	default:
+		return fmt.Sprintf("%d-%d", histBounds[i-1]+1, histBounds[i])
+	}
+}
+
+// Write affiche l'histogramme avec des barres proportionnelles
+func (h *Histogram) Write(w io.Writer) {
+	top := 0
+	for _, c := range h.Counts {
+		top = max(top, c)
+	}
+	for i, c := range h.Counts {
+		bar := 0
+		if top > 0 {
+			bar = c * 30 / top
+		}
+		fmt.Fprintf(w, "  %-8s %7d %s\n", h.Label(i), c, strings.Repeat("#", bar))
+	}
+}
+
+// Stats cumule tokens, caracteres et longueurs de lignes d'un texte
+type Stats struct {
+	Lines     int
+	Words     int
+	WordRunes int
+	Numbers   int
+	Punct     int
+	Chars     CharStats
+	LineHist  Histogram
+	Prose     Metrics // phrases, paragraphes, lisibilite ; diversite si Prose.Vocabulary
+}
+
+// AddLine ajoute une ligne (sans son saut de ligne)
+func (s *Stats) AddLine(line string) {
+	s.Lines++
+	s.Chars.Add(line)
+	s.LineHist.Add(utf8.RuneCountInString(strings.TrimRight(line, "\r")))
+	prose := s.Prose.beginLine(line)
+	Each(line, func(t Token) {
+		if prose {
+			s.Prose.add(t)
+		}
+		switch t.Kind {
+		case Word:
+			s.Words++
+			s.WordRunes += t.Runes
+		case Number:
+			s.Numbers++
+		case Punct:
+			s.Punct++
+		}
+	})
+}
+
+// AddText ajoute un texte complet, ligne par ligne
+func (s *Stats) AddText(text string) {
+	for _, line := range strings.Split(text, "\n") {
+		s.AddLine(line)
+	}
+}
+
+// AvgWordLen renvoie la longueur moyenne des mots en caracteres
+func (s *Stats) AvgWordLen() float64 {
+	if s.Words == 0 {
+		return 0
+	}
+	return float64(s.WordRunes) / float64(s.Words)
+}
@@ -0,0 +1,131 @@
+// Package tokenizer decoupe un texte en mots, nombres et ponctuation en
+// respectant Unicode (lettres accentuees, apostrophes, traits d'union) et
+// compte les longueurs en caracteres (runes), pas en octets.
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind est la categorie d'un token
+type Kind int
+
+const (
+	Word   Kind = iota // lettres, avec apostrophes/tirets internes ("aujourd'hui", "porte-monnaie")
+	Number             // chiffres, avec . ou , entre deux chiffres ("3.14", "1,5")
+	Punct              // ponctuation ou symbole isole
+)
+
+// Token est un morceau du texte
+type Token struct {
+	Text  string
+	Kind  Kind
+	Runes int
+}
+
+// Each appelle fn pour chaque token de s, sans allouer de liste
+func Each(s string, fn func(Token)) {
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case isLetter(r):
+			end, runes := scanWord(s, i)
+			fn(Token{Text: s[i:end], Kind: Word, Runes: runes})
+			i = end
+		case unicode.IsDigit(r):
+			end, runes := scanNumber(s, i)
+			// "3D", "mp3" : un nombre colle a des lettres forme un mot
+			if end < len(s) {
+				if next, _ := utf8.DecodeRuneInString(s[end:]); isLetter(next) {
+					wend, wruns := scanWord(s, end)
+					fn(Token{Text: s[i:wend], Kind: Word, Runes: runes + wruns})
+					i = wend
+					continue
+				}
+			}
+			fn(Token{Text: s[i:end], Kind: Number, Runes: runes})
+			i = end
+		default:
+			fn(Token{Text: s[i : i+size], Kind: Punct, Runes: 1})
+			i += size
+		}
+	}
+}
+
+// Tokenize renvoie tous les tokens de s
+func Tokenize(s string) []Token {
+	var out []Token
+	Each(s, func(t Token) { out = append(out, t) })
+	return out
+}
+
+// Words renvoie les mots de s (ni nombres ni ponctuation)
+func Words(s string) []string {
+	var out []string
+	Each(s, func(t Token) {
+		if t.Kind == Word {
+			out = append(out, t.Text)
+		}
+	})
+	return out
+}
+
+// isLetter accepte aussi les marques combinantes (e + accent combinant)
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r)
+}
+
+func isJoiner(r rune) bool {
+	switch r {
+	case '\'', '’', '-', '‐':
+		return true
+	}
+	return false
+}
+
+// scanWord avance sur lettres et chiffres ; une apostrophe ou un tiret n'est
+// garde que s'il est suivi d'une lettre
+func scanWord(s string, i int) (end, runes int) {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if isLetter(r) || unicode.IsDigit(r) {
+			i += size
+			runes++
+			continue
+		}
+		if isJoiner(r) && i+size < len(s) {
+			if next, _ := utf8.DecodeRuneInString(s[i+size:]); isLetter(next) {
+				i += size
+				runes++
+				continue
+			}
+		}
+		break
+	}
+	return i, runes
+}
+
+// scanNumber avance sur les chiffres ; '.' et ',' sont gardes entre deux chiffres
+func scanNumber(s string, i int) (end, runes int) {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsDigit(r) {
+			i += size
+			runes++
+			continue
+		}
+		if (r == '.' || r == ',') && i+size < len(s) {
+			if next, _ := utf8.DecodeRuneInString(s[i+size:]); unicode.IsDigit(next) {
+				i += size
+				runes++
+				continue
+			}
+		}
+		break
+	}
+	return i, runes
+}
@@ -0,0 +1,98 @@
+package tokenizer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWordsUnicode(t *testing.T) {
+	got := Words(`L'élève, aujourd'hui, a lu 3,5 pages — "porte-monnaie" mp3 et 42.`)
+	want := []string{"L'élève", "aujourd'hui", "a", "lu", "pages", "porte-monnaie", "mp3", "et"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Words = %q, want %q", got, want)
+	}
+}
+
+func TestTokenizeKindsAndRunes(t *testing.T) {
+	toks := Tokenize("élève, 3.14 -")
+	want := []Token{
+		{"élève", Word, 5},
+		{",", Punct, 1},
+		{"3.14", Number, 4},
+		{"-", Punct, 1},
+	}
+	if !reflect.DeepEqual(toks, want) {
+		t.Fatalf("Tokenize = %+v, want %+v", toks, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var s Stats
+	s.AddText("Été 2024 : élève.\n\nabc")
+	if s.Lines != 3 || s.Words != 3 || s.Numbers != 1 || s.Punct != 2 {
+		t.Fatalf("unexpected counts: %+v", s)
+	}
+	if s.AvgWordLen() != 11.0/3 {
+		t.Fatalf("avg = %v, want rune based 11/3", s.AvgWordLen())
+	}
+	if s.Chars.Upper != 1 || s.Chars.Letters != 11 || s.Chars.Digits != 4 {
+		t.Fatalf("unexpected char stats: %+v", s.Chars)
+	}
+	if s.LineHist.Counts[0] != 1 || s.LineHist.Counts[1] != 2 || s.LineHist.Max != 17 {
+		t.Fatalf("unexpected histogram: %+v", s.LineHist)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var m Metrics
+	m.AddText("Titre sans point\n\nLe chat dort. M. Dupont lit un livre\nde 2024.\n\n\nFin ?!")
+	if m.Paragraphs != 3 || m.Sentences() != 4 || m.Words != 13 {
+		t.Fatalf("paragraphs=%d sentences=%d words=%d", m.Paragraphs, m.Sentences(), m.Words)
+	}
+	if m.AvgSentenceLen() != 13.0/4 {
+		t.Fatalf("avg sentence = %v", m.AvgSentenceLen())
+	}
+
+	en := Metrics{Vocabulary: true}
+	en.AddText("The cat sat on the mat. It was happy!")
+	if en.Types() != 8 || en.TTR() != 8.0/9 {
+		t.Fatalf("types=%d ttr=%v", en.Types(), en.TTR())
+	}
+	score, formula := en.Readability("en")
+	if formula != "Flesch" || math.Abs(score-(206.835-1.015*4.5-84.6*10/9)) > 1e-9 {
+		t.Fatalf("flesch = %v (%s)", score, formula)
+	}
+	if _, formula := en.Readability("fr"); formula != "Kandel-Moles" {
+		t.Fatalf("french formula = %s", formula)
+	}
+	if ReadabilityLabel(score) != "tres facile" || ReadabilityLabel(10) != "tres difficile" {
+		t.Fatalf("labels")
+	}
+}
+
+func TestSyllables(t *testing.T) {
+	cases := []struct {
+		word   string
+		en, fr int
+	}{
+		{"happy", 2, 2}, {"made", 1, 1}, {"table", 2, 1}, {"livres", 2, 1},
+		{"Dupont", 2, 2}, {"élève", 2, 2}, {"le", 1, 1}, {"xyz", 1, 1},
+	}
+	for _, c := range cases {
+		if en, fr := syllables(c.word); en != c.en || fr != c.fr {
+			t.Fatalf("syllables(%s) = %d/%d, want %d/%d", c.word, en, fr, c.en, c.fr)
+		}
+	}
+}
+
+func TestStatsFeedsMetrics(t *testing.T) {
+	var s Stats
+	s.AddText("Un. Deux.\n\nTrois")
+	if s.Prose.Sentences() != 3 || s.Prose.Paragraphs != 2 || s.Prose.Words != s.Words {
+		t.Fatalf("prose = %+v", s.Prose)
+	}
+	if s.Prose.Types() != 0 {
+		t.Fatalf("vocabulary counted without opt-in: %d types", s.Prose.Types())
+	}
+}
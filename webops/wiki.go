@@ -11,18 +11,28 @@ import (
 	"unicode"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"gotools/config"
+	"gotools/tokenizer"
 )
 
+var settings = config.DefaultConfig().WebOps
+
+// Configure applique la section webops de la config
+func Configure(c config.WebOpsConfig) {
+	settings = c
+}
+
 func FetchArticle(article, lang string) (string, error) {
 	url := fmt.Sprintf("https://%s.wikipedia.org/wiki/%s", lang, article)
 	fmt.Printf("  Recuperation de %s...\n", url)
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: time.Duration(settings.TimeoutSec) * time.Second}
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("erreur creation requete HTTP: %w", err)
 	}
-	req.Header.Set("User-Agent", "GoTools/1.0 (+M1 DevOps project)")
+	req.Header.Set("User-Agent", settings.UserAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml")
 	req.Header.Set("Accept-Language", lang)
 
@@ -63,27 +73,30 @@ func AnalyzeArticle(article, lang, outDir string) error {
 		return fmt.Errorf("aucun contenu pour '%s'", article)
 	}
 
-	// stat 1 : nb mots (sans les numeriques)
-	words := extractWordsFromText(text)
-	totalLen := 0
-	for _, w := range words {
-		totalLen += len(w)
-	}
-	avg := 0.0
-	if len(words) > 0 {
-		avg = float64(totalLen) / float64(len(words))
-	}
-	fmt.Printf("  Mots (hors numeriques) : %d\n", len(words))
-	fmt.Printf("  Longueur moyenne       : %.1f\n", avg)
+	// stat 1 : nb mots (sans les numeriques), longueur en caracteres
+	var ts tokenizer.Stats
+	ts.Prose.Vocabulary = true // un article tient en memoire
+	ts.AddText(text)
+	avg := ts.AvgWordLen()
+	fmt.Printf("  Mots (hors numeriques) : %d\n", ts.Words)
+	fmt.Printf("  Longueur moyenne       : %.1f caracteres\n", avg)
+	fmt.Printf("  Caracteres             : %d (lettres %d, chiffres %d, ponctuation %d)\n",
+		ts.Chars.Runes, ts.Chars.Letters, ts.Chars.Digits, ts.Chars.Punct)
 
-	// stat 2 : nb de paragraphes
-	paras := strings.Split(text, "\n\n")
-	fmt.Printf("  Paragraphes            : %d\n", len(paras))
+	// stat 2 : paragraphes, phrases, diversite et lisibilite (formule selon la langue)
+	p := &ts.Prose
+	score, formula := p.Readability(lang)
+	fmt.Printf("  Paragraphes            : %d\n", p.Paragraphs)
+	fmt.Printf("  Phrases                : %d (%.1f mots en moyenne)\n", p.Sentences(), p.AvgSentenceLen())
+	fmt.Printf("  Diversite lexicale     : %.3f (%d mots distincts)\n", p.TTR(), p.Types())
+	if formula != "" {
+		fmt.Printf("  Lisibilite             : %.1f (%s, %s)\n", score, formula, tokenizer.ReadabilityLabel(score))
+	}
 
 	// sauvegarde
 	outPath := filepath.Join(outDir, "wiki_"+safeFilePart(article)+".txt")
-	content := fmt.Sprintf("=== %s ===\nMots: %d | Moy: %.1f | Paragraphes: %d\n\n%s\n",
-		article, len(words), avg, len(paras), text)
+	content := fmt.Sprintf("=== %s ===\nMots: %d | Moy: %.1f | Paragraphes: %d | Phrases: %d | TTR: %.3f | Lisibilite: %.1f\n\n%s\n",
+		article, ts.Words, avg, p.Paragraphs, p.Sentences(), p.TTR(), score, text)
 
 	if err := os.WriteFile(outPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("erreur ecriture: %w", err)
@@ -121,23 +134,9 @@ func AnalyzeArticlesParallel(articles []string, lang, outDir string) {
 	}
 }
 
+// extractWordsFromText renvoie les mots de l'article (ni nombres ni ponctuation)
 func extractWordsFromText(text string) []string {
-	var words []string
-	for _, w := range strings.Fields(text) {
-		if !isNumeric(w) {
-			words = append(words, w)
-		}
-	}
-	return words
-}
-
-func isNumeric(s string) bool {
-	for _, r := range s {
-		if !unicode.IsDigit(r) && r != '.' && r != ',' {
-			return false
-		}
-	}
-	return true
+	return tokenizer.Words(text)
 }
 
 func safeFilePart(s string) string {